@@ -0,0 +1,68 @@
+package jsonseq
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// EncodeArray reads a top-level JSON array from r, and writes each element to w
+// as a record, without holding the whole array in memory. Element bytes are
+// copied as is. It returns the number of records written.
+func EncodeArray(w io.Writer, r io.Reader) (int, error) {
+	d := json.NewDecoder(r)
+	t, err := d.Token()
+	if err != nil {
+		return 0, err
+	}
+	if t != json.Delim('[') {
+		return 0, fmt.Errorf("expected array but got %v", t)
+	}
+	var n int
+	for d.More() {
+		var raw json.RawMessage
+		if err := d.Decode(&raw); err != nil {
+			return n, fmt.Errorf("element %d: %w", n, err)
+		}
+		if err := WriteRecord(w, raw); err != nil {
+			return n, fmt.Errorf("element %d: %w", n, err)
+		}
+		n++
+	}
+	if _, err := d.Token(); err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+// DecodeToArray reads records from r, and writes their values to w as a single
+// JSON array. Value bytes are copied as is, so numbers and key order are
+// preserved. Each record must hold a single value. An empty sequence is written
+// as []. It returns the number of elements written, and the first error
+// annotated with its record index.
+func DecodeToArray(w io.Writer, r io.Reader) (int, error) {
+	d := NewDecoder(r)
+	bw := bufio.NewWriter(w)
+	_ = bw.WriteByte('[')
+	var n int
+	for ; ; n++ {
+		b, err := d.nextValue()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return n, fmt.Errorf("record %d: %w", n, err)
+		}
+		if err := checkSingleValue(b); err != nil {
+			return n, fmt.Errorf("record %d: %w", n, err)
+		}
+		if n > 0 {
+			_ = bw.WriteByte(',')
+		}
+		if _, err := bw.Write(b); err != nil {
+			return n, fmt.Errorf("record %d: %w", n, err)
+		}
+	}
+	_ = bw.WriteByte(']')
+	return n, bw.Flush()
+}
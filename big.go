@@ -0,0 +1,68 @@
+package jsonseq
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// DecodeBig is a Decode function which preserves the precision of numbers,
+// for use with NewDecoderFn. Like the default, it decodes the first value and
+// discards any remaining data.
+//
+// When v is a *interface{}, each number is decoded as a *big.Int if it is an
+// integer, or a *big.Float otherwise, nested within the usual
+// map[string]interface{} and []interface{} values. Other targets are decoded
+// with json.Decoder.UseNumber, so that json.Number and *big.Int fields are
+// populated without loss.
+//
+// Every number requires at least one extra allocation, and decoded values are
+// walked a second time to convert them, so DecodeBig is substantially slower
+// than the default Decode function. Prefer it only when values may exceed the
+// range or precision of float64.
+func DecodeBig(b []byte, v interface{}) error {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(v); err != nil {
+		return err
+	}
+	if p, ok := v.(*interface{}); ok {
+		var err error
+		*p, err = toBig(*p)
+		return err
+	}
+	return nil
+}
+
+// toBig replaces json.Numbers in v with *big.Int or *big.Float values.
+func toBig(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case json.Number:
+		s := string(t)
+		if i, ok := new(big.Int).SetString(s, 10); ok {
+			return i, nil
+		}
+		// Roughly 3.3 bits per decimal digit, with room to spare.
+		f, ok := new(big.Float).SetPrec(uint(len(s))*4 + 64).SetString(s)
+		if !ok {
+			return nil, fmt.Errorf("invalid number: %q", s)
+		}
+		return f, nil
+	case map[string]interface{}:
+		for k, e := range t {
+			var err error
+			if t[k], err = toBig(e); err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		for i, e := range t {
+			var err error
+			if t[i], err = toBig(e); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,57 @@
+package jsonseq
+
+import "io"
+
+// A BufferedWriter batches records in memory, and writes them to the
+// underlying io.Writer in as few calls as possible. Callers must call Flush
+// after the last record.
+type BufferedWriter struct {
+	w    io.Writer
+	buf  []byte
+	size int
+}
+
+// NewBufferedWriter returns a new BufferedWriter writing to w, which flushes
+// automatically once at least size bytes are buffered. If size is not
+// positive, a default of 4096 is used.
+func NewBufferedWriter(w io.Writer, size int) *BufferedWriter {
+	if size <= 0 {
+		size = 4096
+	}
+	return &BufferedWriter{w: w, buf: make([]byte, 0, size), size: size}
+}
+
+// WriteRecord buffers a JSON text sequence record, as with WriteRecord, and
+// flushes if the buffer is full.
+func (b *BufferedWriter) WriteRecord(json []byte) error {
+	b.buf = AppendRecord(b.buf, json)
+	if len(b.buf) >= b.size {
+		return b.Flush()
+	}
+	return nil
+}
+
+// Buffered returns the number of bytes buffered.
+func (b *BufferedWriter) Buffered() int {
+	return len(b.buf)
+}
+
+// Flush writes any buffered records to the underlying io.Writer. After an
+// error, the unwritten bytes remain buffered.
+func (b *BufferedWriter) Flush() error {
+	if len(b.buf) == 0 {
+		return nil
+	}
+	n, err := b.w.Write(b.buf)
+	if err == nil && n < len(b.buf) {
+		err = io.ErrShortWrite
+	}
+	b.buf = b.buf[:copy(b.buf, b.buf[n:])]
+	return err
+}
+
+// Reset discards any buffered records, and resets b to write to w.
+func (b *BufferedWriter) Reset(w io.Writer) {
+	b.w = w
+	b.buf = b.buf[:0]
+}
@@ -0,0 +1,74 @@
+package jsonseq
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// CompressedStringDecode returns a Decode function for records holding a JSON
+// string of base64 encoded, gzip compressed JSON. Each string is decoded and
+// decompressed, and the inner JSON passed to fn. Like other Decode functions,
+// it does not know the record index, which callers may add, as with
+// DecodeNumbered.
+func CompressedStringDecode(fn Decode) Decode {
+	return func(b []byte, v interface{}) error {
+		var s string
+		if err := decodeFirst(b, &s); err != nil {
+			return fmt.Errorf("invalid compressed string: %w", err)
+		}
+		z, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return fmt.Errorf("invalid base64: %w", err)
+		}
+		zr, err := gzip.NewReader(bytes.NewReader(z))
+		if err != nil {
+			return fmt.Errorf("invalid gzip: %w", err)
+		}
+		inner, err := io.ReadAll(zr)
+		if err != nil {
+			return fmt.Errorf("invalid gzip: %w", err)
+		}
+		return fn(inner, v)
+	}
+}
+
+// CompressedStringMarshal returns the JSON encoding of v, gzip compressed,
+// base64 encoded, and wrapped in a JSON string. It is the inverse of
+// CompressedStringDecode.
+func CompressedStringMarshal(v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var z bytes.Buffer
+	zw := gzip.NewWriter(&z)
+	if _, err := zw.Write(b); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(base64.StdEncoding.EncodeToString(z.Bytes()))
+}
+
+// NewGzipDecoder returns a Decoder reading a gzip compressed sequence from r,
+// or an error if the gzip header is invalid.
+func NewGzipDecoder(r io.Reader) (*Decoder, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return NewDecoder(zr), nil
+}
+
+// NewGzipEncoder returns an Encoder writing a gzip compressed sequence to w,
+// along with the gzip.Writer, which the caller must Close to flush the
+// compressed stream.
+func NewGzipEncoder(w io.Writer) (*Encoder, *gzip.Writer) {
+	zw := gzip.NewWriter(w)
+	return NewEncoderT(zw), zw
+}
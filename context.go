@@ -0,0 +1,76 @@
+package jsonseq
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+)
+
+// A ctxReader is an io.Reader which abandons blocked reads once its context is
+// done. Without a context, reads pass straight through.
+type ctxReader struct {
+	r   io.Reader
+	ctx context.Context
+
+	buf []byte // Reused, unless abandoned.
+	err error  // Set when a read is abandoned.
+}
+
+type readResult struct {
+	n   int
+	err error
+}
+
+func (c *ctxReader) Read(p []byte) (int, error) {
+	if c.ctx == nil || c.ctx.Done() == nil {
+		return c.r.Read(p)
+	}
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	if cap(c.buf) < len(p) {
+		c.buf = make([]byte, len(p))
+	}
+	buf := c.buf[:len(p)]
+	ch := make(chan readResult, 1)
+	// An abandoned read may outlive c.r, which Reset replaces.
+	r := c.r
+	go func() {
+		n, err := r.Read(buf)
+		ch <- readResult{n, err}
+	}()
+	select {
+	case res := <-ch:
+		return copy(p, buf[:res.n]), res.err
+	case <-c.ctx.Done():
+		// The read may still complete, so buf must not be reused.
+		c.buf = nil
+		c.err = c.ctx.Err()
+		return 0, c.err
+	}
+}
+
+// Stream sends a copy of the value bytes of each remaining record to out, as
+// with DecodeRaw, until the end of the input, an error, or ctx is done. It
+// returns nil at the end of the input. The caller remains responsible for
+// closing out.
+func (d *Decoder) Stream(ctx context.Context, out chan<- json.RawMessage) error {
+	d.r.ctx = ctx
+	defer func() { d.r.ctx = nil }()
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		raw, err := d.DecodeRaw()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		select {
+		case out <- raw:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
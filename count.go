@@ -0,0 +1,50 @@
+package jsonseq
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// A CountHeader is the first record of a counted sequence, as written by
+// WriteWithCount. It is an ordinary JSON object record, so decoders which are
+// unaware of the convention simply see it as the first value:
+//
+//	\x1e{"count":2}\n
+//	\x1e{"id":1}\n
+//	\x1e{"id":2}\n
+type CountHeader struct {
+	Count int `json:"count"`
+}
+
+// WriteWithCount writes a CountHeader record holding the number of records,
+// followed by each of the records.
+func WriteWithCount(w io.Writer, records [][]byte) error {
+	header := `{"count":` + strconv.Itoa(len(records)) + `}`
+	if err := WriteRecord(w, []byte(header)); err != nil {
+		return err
+	}
+	for _, r := range records {
+		if err := WriteRecord(w, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecodeCount decodes the CountHeader record written by WriteWithCount, and
+// returns the number of records which follow it. It must be called before any
+// other records are decoded.
+func DecodeCount(d *Decoder) (int, error) {
+	var h CountHeader
+	if err := d.Decode(&h); err != nil {
+		if err == io.EOF {
+			return 0, io.ErrUnexpectedEOF
+		}
+		return 0, fmt.Errorf("invalid count header: %w", err)
+	}
+	if h.Count < 0 {
+		return 0, fmt.Errorf("invalid count header: negative count %d", h.Count)
+	}
+	return h.Count, nil
+}
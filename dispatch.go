@@ -0,0 +1,72 @@
+package jsonseq
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrUnknownType is returned by Dispatcher.Decode for a record whose type is
+// not registered.
+var ErrUnknownType = errors.New("unknown record type")
+
+// A Dispatcher decodes records of a heterogeneous sequence into values chosen
+// by a discriminator field of each record's top-level object. The zero value
+// is ready to use, with the field "type".
+type Dispatcher struct {
+	Field string // The discriminator field, or "type" if empty.
+
+	types map[string]func() interface{}
+}
+
+// Register registers newFn to allocate the value, typically a pointer to a
+// struct, which records of the type typeName are decoded into.
+func (p *Dispatcher) Register(typeName string, newFn func() interface{}) {
+	if p.types == nil {
+		p.types = make(map[string]func() interface{})
+	}
+	p.types[typeName] = newFn
+}
+
+// Decode peeks the next record from d, reads its discriminator field, and
+// decodes it with d into a new value of the registered type, which is
+// returned. An invalid record, or one with an unregistered type, is consumed,
+// and an error is returned, wrapping ErrUnknownType for the latter. A missing
+// field is the empty type name. Decode returns io.EOF when no records remain.
+func (p *Dispatcher) Decode(d *Decoder) (interface{}, error) {
+	raw, err := d.Peek()
+	if err != nil {
+		if err != io.EOF {
+			// Peek leaves invalid records buffered.
+			_, _ = d.consume()
+		}
+		return nil, err
+	}
+	field := p.Field
+	if field == "" {
+		field = "type"
+	}
+	var obj map[string]json.RawMessage
+	if err := decodeFirst(raw, &obj); err != nil {
+		_, _ = d.consume()
+		return nil, err
+	}
+	var name string
+	if t, ok := obj[field]; ok {
+		if err := json.Unmarshal(t, &name); err != nil {
+			_, _ = d.consume()
+			return nil, fmt.Errorf("invalid %s field: %w", field, err)
+		}
+	}
+	newFn, ok := p.types[name]
+	if !ok {
+		_, _ = d.consume()
+		return nil, fmt.Errorf("%w: %q", ErrUnknownType, name)
+	}
+	v := newFn()
+	if err := d.Decode(v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,154 @@
+package jsonseq
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+)
+
+// An Encoder writes JSON values to an output stream as a JSON text sequence.
+type Encoder struct {
+	w   io.Writer
+	buf bytes.Buffer
+	enc *json.Encoder // Encodes into buf.
+	fn  func(v interface{}) ([]byte, error)
+	sep Separators
+	n   int
+
+	autoFlush      bool
+	preamble       int
+	lengthPrefixed bool
+}
+
+// NewEncoderT returns a new Encoder that writes to w. Unlike NewEncoder, which
+// returns a standard library json.Encoder, the Encoder tracks the records it
+// has written.
+func NewEncoderT(w io.Writer) *Encoder {
+	e := &Encoder{w: w, sep: DefaultSeparators}
+	e.enc = json.NewEncoder(&e.buf)
+	return e
+}
+
+// NewEncoderFn returns a new Encoder that writes to w, backed by a custom
+// marshal function rather than the standard library json.Encoder. Any trailing
+// whitespace from marshal is replaced with a single line feed. SetEscapeHTML and
+// SetIndent have no effect.
+func NewEncoderFn(w io.Writer, marshal func(v interface{}) ([]byte, error)) *Encoder {
+	e := NewEncoderT(w)
+	e.fn = marshal
+	return e
+}
+
+// Encode writes the JSON encoding of v as a record, with a trailing line feed.
+// Each record is written with a single call to Write.
+func (e *Encoder) Encode(v interface{}) error {
+	e.begin()
+	if e.fn != nil {
+		b, err := e.fn(v)
+		if err != nil {
+			return err
+		}
+		e.buf.Write(bytes.TrimRightFunc(b, wsRune))
+	} else {
+		if err := e.enc.Encode(v); err != nil {
+			return err
+		}
+		// Drop the line feed always appended by json.Encoder.
+		e.buf.Truncate(e.buf.Len() - 1)
+	}
+	return e.end()
+}
+
+// EncodeRaw writes raw as a record verbatim, without marshaling, except that
+// any trailing whitespace is replaced with a single line feed. The bytes are not
+// validated.
+func (e *Encoder) EncodeRaw(raw json.RawMessage) error {
+	e.begin()
+	e.buf.Write(bytes.TrimRightFunc(raw, wsRune))
+	return e.end()
+}
+
+// begin starts a new record in buf.
+func (e *Encoder) begin() {
+	e.buf.Reset()
+	if e.lengthPrefixed {
+		// Reserve room for the length.
+		e.buf.Write(noLength[:])
+		return
+	}
+	if e.n == 0 {
+		for i := 0; i < e.preamble; i++ {
+			e.buf.WriteByte(e.sep.Start)
+		}
+	}
+	e.buf.WriteByte(e.sep.Start)
+}
+
+// end terminates the record in buf, and writes it.
+func (e *Encoder) end() error {
+	if e.lengthPrefixed {
+		b := e.buf.Bytes()
+		binary.BigEndian.PutUint32(b, uint32(len(b)-4))
+	} else if e.sep.End != 0 {
+		e.buf.WriteByte(e.sep.End)
+	}
+	if _, err := e.w.Write(e.buf.Bytes()); err != nil {
+		return err
+	}
+	e.n++
+	if e.autoFlush {
+		return e.flush()
+	}
+	return nil
+}
+
+// SetPreamble sets a number of extra RS bytes to write ahead of the first
+// record, so that a reader joining the stream late can synchronize. Decoders
+// skip consecutive RS bytes, so the preamble is not decoded as records. The
+// default is zero.
+func (e *Encoder) SetPreamble(n int) {
+	e.preamble = n
+}
+
+// SetAutoFlush controls whether the underlying writer is flushed after each
+// record is written, if it has a Flush method like http.Flusher or
+// bufio.Writer. This delivers records progressively, at the cost of throughput.
+// The default is off.
+func (e *Encoder) SetAutoFlush(on bool) {
+	e.autoFlush = on
+}
+
+// flush flushes the underlying writer, if it supports flushing.
+func (e *Encoder) flush() error {
+	switch f := e.w.(type) {
+	case interface{ Flush() error }:
+		return f.Flush()
+	case interface{ Flush() }: // http.Flusher
+		f.Flush()
+	}
+	return nil
+}
+
+// Reset resets e to write to w, and resets its record count, while retaining
+// its settings.
+func (e *Encoder) Reset(w io.Writer) {
+	e.w = w
+	e.n = 0
+}
+
+// Count returns the number of records written.
+func (e *Encoder) Count() int {
+	return e.n
+}
+
+// SetEscapeHTML is like json.Encoder.SetEscapeHTML.
+func (e *Encoder) SetEscapeHTML(on bool) {
+	e.enc.SetEscapeHTML(on)
+}
+
+// SetIndent is like json.Encoder.SetIndent. Indentation only adds whitespace
+// within each value, and records are still terminated by a single End byte.
+func (e *Encoder) SetIndent(prefix, indent string) {
+	e.enc.SetIndent(prefix, indent)
+}
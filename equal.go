@@ -0,0 +1,41 @@
+package jsonseq
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// Equal reports whether the sequences a and b hold semantically equal records,
+// as with Diff.
+func Equal(a, b []byte) (bool, error) {
+	i, err := Diff(a, b)
+	return i < 0, err
+}
+
+// Diff decodes the sequences a and b as with NewDecoder, and returns the index
+// of the first record whose values are not equal per reflect.DeepEqual, or -1
+// if all records are equal. Differences in whitespace, object key order, and
+// number formatting are ignored. If one sequence is longer, the index of its
+// first extra record is returned. Decoding errors are annotated with the record
+// index.
+func Diff(a, b []byte) (int, error) {
+	da, db := NewDecoder(bytes.NewReader(a)), NewDecoder(bytes.NewReader(b))
+	for i := 0; ; i++ {
+		var va, vb interface{}
+		errA, errB := da.Decode(&va), db.Decode(&vb)
+		if errA == io.EOF && errB == io.EOF {
+			return -1, nil
+		}
+		if errA != nil && errA != io.EOF {
+			return i, fmt.Errorf("a: record %d: %w", i, errA)
+		}
+		if errB != nil && errB != io.EOF {
+			return i, fmt.Errorf("b: record %d: %w", i, errB)
+		}
+		if errA != nil || errB != nil || !reflect.DeepEqual(va, vb) {
+			return i, nil
+		}
+	}
+}
@@ -0,0 +1,25 @@
+//go:build go1.23
+
+package jsonseq
+
+import (
+	"fmt"
+	"strings"
+)
+
+func ExampleDecoder_All() {
+	d := NewDecoder(strings.NewReader(`{"id":1} 12341234 true discarded junk`))
+	for raw, err := range d.All() {
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		fmt.Println(string(raw))
+	}
+
+	// Output:
+	// {"id":1}
+	// invalid record: "1234"
+	// 1234
+	// true discarded junk
+}
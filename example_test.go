@@ -1,8 +1,12 @@
 package jsonseq
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"math/big"
 	"os"
 	"strings"
 )
@@ -52,3 +56,694 @@ func ExampleDecoder_Decode() {
 	// 1234
 	// true
 }
+
+func ExampleWriteWithCount() {
+	var b bytes.Buffer
+	_ = WriteWithCount(&b, [][]byte{[]byte(`{"id":1}`), []byte(`{"id":2}`)})
+
+	d := NewDecoder(&b)
+	n, _ := DecodeCount(d)
+	fmt.Println("count:", n)
+	for i := 0; i < n; i++ {
+		var v interface{}
+		_ = d.Decode(&v)
+		fmt.Println(v)
+	}
+
+	// Output:
+	// count: 2
+	// map[id:1]
+	// map[id:2]
+}
+
+func ExampleCompressedStringDecode() {
+	var b bytes.Buffer
+	for _, v := range []interface{}{map[string]int{"id": 1}, "Test"} {
+		record, _ := CompressedStringMarshal(v)
+		_ = WriteRecord(&b, record)
+	}
+
+	_ = WriteRecord(&b, []byte(`"!"`))
+
+	d := NewDecoderFn(&b, CompressedStringDecode(json.Unmarshal))
+	for {
+		var v interface{}
+		if n, err := d.DecodeNumbered(&v); err != nil {
+			if err == io.EOF {
+				break
+			}
+			fmt.Printf("record %d: %v\n", n, err)
+		} else {
+			fmt.Println(v)
+		}
+	}
+
+	// Output:
+	// map[id:1]
+	// Test
+	// record 3: invalid base64: illegal base64 data at input byte 0
+}
+
+func ExamplePrettyDiffForm() {
+	in := "\x1e{\"b\":[1,2],\"a\":1.50}\n\x1etrue\n"
+	var b bytes.Buffer
+	_ = PrettyDiffForm(strings.NewReader(in), &b)
+	fmt.Print(b.String())
+
+	// Output:
+	// {
+	//   "a": 1.50,
+	//   "b": [
+	//     1,
+	//     2
+	//   ]
+	// }
+	// true
+}
+
+func ExampleDecodeBig() {
+	d := NewDecoderFn(strings.NewReader("\x1e{\"n\":123456789012345678901234567890,\"f\":1.000000000000000000001}\n"), DecodeBig)
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		fmt.Println(err)
+	}
+	m := v.(map[string]interface{})
+	fmt.Println(m["n"])
+	fmt.Println(m["f"].(*big.Float).Text('f', 21))
+
+	// Output:
+	// 123456789012345678901234567890
+	// 1.000000000000000000001
+}
+
+func ExampleShard() {
+	in := "\x1e{\"id\":1}\n\x1e{\"id\":2}\n\x1e{\"id\":3}\n"
+	var a, b bytes.Buffer
+	n, _ := Shard(strings.NewReader(in), []io.Writer{&a, &b}, nil)
+	fmt.Println(n)
+	fmt.Print(a.String(), b.String())
+
+	// Output:
+	// 3
+	// {"id":1}
+	// {"id":3}
+	// {"id":2}
+}
+
+func ExampleDecoder_More() {
+	d := NewDecoder(strings.NewReader(`{"id":1} {"id":2} {"id":3}`))
+	var n int
+	for d.More() {
+		var i interface{}
+		if err := d.Decode(&i); err != nil {
+			fmt.Println(err)
+			continue
+		}
+		n++
+	}
+	if err := d.Err(); err != nil {
+		fmt.Println(err)
+	}
+	fmt.Println("decoded", n, "records")
+
+	// Output:
+	// decoded 3 records
+}
+
+func ExampleDecoder_InputOffset() {
+	d := NewDecoder(strings.NewReader("\x1e{\"id\":1}\n\x1e\x1e{\"id\":2}\n\x1etrue\n"))
+	for {
+		var v interface{}
+		if err := d.Decode(&v); err == io.EOF {
+			break
+		}
+		fmt.Println(d.InputOffset(), v)
+	}
+
+	// Output:
+	// 0 map[id:1]
+	// 11 map[id:2]
+	// 21 true
+}
+
+func ExampleCollect() {
+	type record struct {
+		ID int `json:"id"`
+	}
+	rs, err := Collect[record](strings.NewReader("\x1e{\"id\":1}\n\x1e{\"id\":2}\n"))
+	if err != nil {
+		fmt.Println(err)
+	}
+	fmt.Println(rs)
+
+	// Output:
+	// [{1} {2}]
+}
+
+func ExampleDecoder_SetSkipInvalid() {
+	d := NewDecoder(strings.NewReader("\x1e{\"id\":1}\n\x1e1234\x1etrue\n"))
+	d.SetSkipInvalid(true)
+	for {
+		var i interface{}
+		if err := d.Decode(&i); err != nil {
+			if err == io.EOF {
+				break
+			}
+			fmt.Println(err)
+		} else {
+			fmt.Println(i)
+		}
+	}
+	fmt.Printf("skipped: %q\n", d.Skipped())
+
+	// Output:
+	// map[id:1]
+	// true
+	// skipped: ["\x1e1234"]
+}
+
+func ExampleNewEncoderT() {
+	encoder := NewEncoderT(os.Stdout)
+	_ = encoder.Encode("Test")
+	_ = encoder.Encode(123.456)
+	fmt.Println("records:", encoder.Count())
+
+	// Output:
+	// "Test"
+	// 123.456
+	// records: 2
+}
+
+func ExampleDecoder_DecodeEach() {
+	d := NewDecoder(strings.NewReader("\x1e{\"id\":1}\n\x1e[1,2]\n\x1enull\n"))
+	err := d.DecodeEach(func(raw json.RawMessage) error {
+		fmt.Println(string(raw))
+		return nil
+	})
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// {"id":1}
+	// [1,2]
+	// null
+}
+
+func ExampleRecordReader() {
+	rr := NewRecordReader(strings.NewReader("\x1e{\"id\":1}\n\x1e{\"id\":2}\n"))
+	for {
+		r, err := rr.Next()
+		if err != nil {
+			if err != io.EOF {
+				fmt.Println(err)
+			}
+			break
+		}
+		var v interface{}
+		if err := json.NewDecoder(r).Decode(&v); err != nil {
+			fmt.Println(err)
+			continue
+		}
+		fmt.Println(v)
+	}
+
+	// Output:
+	// map[id:1]
+	// map[id:2]
+}
+
+func ExampleNewNDJSONDecoder() {
+	d := NewNDJSONDecoder(strings.NewReader("{\"id\":1}\n\n[1,2]\ntrue"))
+	for {
+		var v interface{}
+		if err := d.Decode(&v); err != nil {
+			if err != io.EOF {
+				fmt.Println(err)
+			}
+			break
+		}
+		fmt.Println(v)
+	}
+
+	// Output:
+	// map[id:1]
+	// [1 2]
+	// true
+}
+
+func ExampleToNDJSON() {
+	_, _ = ToNDJSON(os.Stdout, strings.NewReader("\x1e{\n  \"id\": 1\n}\n\x1e[1, 2]\n"))
+
+	// Output:
+	// {"id":1}
+	// [1,2]
+}
+
+func ExampleEncodeArray() {
+	_, _ = EncodeArray(os.Stdout, strings.NewReader(`[{"id":1}, "Test", 123.456]`))
+
+	// Output:
+	// {"id":1}
+	// "Test"
+	// 123.456
+}
+
+func ExampleDecodeToArray() {
+	_, _ = DecodeToArray(os.Stdout, strings.NewReader("\x1e{\"id\":1}\n\x1e\"Test\"\n\x1e123\n"))
+	fmt.Println()
+	_, _ = DecodeToArray(os.Stdout, strings.NewReader(""))
+
+	// Output:
+	// [{"id":1},"Test",123]
+	// []
+}
+
+func ExampleDecoder_Peek() {
+	d := NewDecoder(strings.NewReader("\x1e{\"type\":\"point\",\"x\":1,\"y\":2}\n\x1e{\"type\":\"name\",\"name\":\"Test\"}\n"))
+	for {
+		raw, err := d.Peek()
+		if err != nil {
+			if err != io.EOF {
+				fmt.Println(err)
+			}
+			break
+		}
+		var kind struct{ Type string }
+		_ = json.Unmarshal(raw, &kind)
+		switch kind.Type {
+		case "point":
+			var p struct{ X, Y int }
+			_ = d.Decode(&p)
+			fmt.Println("point:", p.X, p.Y)
+		case "name":
+			var n struct{ Name string }
+			_ = d.Decode(&n)
+			fmt.Println("name:", n.Name)
+		}
+	}
+
+	// Output:
+	// point: 1 2
+	// name: Test
+}
+
+func ExampleDecoder_Stream() {
+	d := NewDecoder(strings.NewReader("\x1e{\"id\":1}\n\x1e{\"id\":2}\n\x1e{\"id\":3}\n"))
+	out := make(chan json.RawMessage)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for raw := range out {
+			fmt.Println(string(raw))
+		}
+	}()
+	if err := d.Stream(context.Background(), out); err != nil {
+		fmt.Println(err)
+	}
+	close(out)
+	<-done
+
+	// Output:
+	// {"id":1}
+	// {"id":2}
+	// {"id":3}
+}
+
+func ExampleNewGzipEncoder() {
+	var b bytes.Buffer
+	e, zw := NewGzipEncoder(&b)
+	_ = e.Encode(map[string]int{"id": 1})
+	_ = e.Encode("Test")
+	_ = zw.Close()
+
+	d, err := NewGzipDecoder(&b)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for {
+		var v interface{}
+		if err := d.Decode(&v); err != nil {
+			if err != io.EOF {
+				fmt.Println(err)
+			}
+			break
+		}
+		fmt.Println(v)
+	}
+
+	// Output:
+	// map[id:1]
+	// Test
+}
+
+func ExampleSeparators() {
+	// A dialect with RS separators, but no trailing line feeds.
+	sep := Separators{Start: '\x1e'}
+
+	var b bytes.Buffer
+	e := NewEncoderT(&b)
+	_ = e.SetSeparators(sep)
+	_ = e.Encode(map[string]int{"id": 1})
+	_ = e.Encode(1234)
+	fmt.Printf("%q\n", b.String())
+
+	d := NewDecoder(&b)
+	_ = d.SetSeparators(sep)
+	for {
+		var v interface{}
+		if err := d.Decode(&v); err != nil {
+			if err != io.EOF {
+				fmt.Println(err)
+			}
+			break
+		}
+		fmt.Println(v)
+	}
+
+	// Output:
+	// "\x1e{\"id\":1}\x1e1234"
+	// map[id:1]
+	// 1234
+}
+
+func ExampleAppendRecord() {
+	var buf []byte
+	buf = AppendRecord(buf, []byte(`{"id":1}`))
+	buf = AppendRecord(buf, []byte(`{"id":2}`))
+	_, _ = os.Stdout.Write(buf)
+
+	// Output:
+	// {"id":1}
+	// {"id":2}
+}
+
+func ExampleNewEncoderFn() {
+	encoder := NewEncoderFn(os.Stdout, func(v interface{}) ([]byte, error) {
+		return json.MarshalIndent(v, "", "  ")
+	})
+	_ = encoder.Encode(map[string]int{"id": 1})
+
+	// Output:
+	// {
+	//   "id": 1
+	// }
+}
+
+func ExampleNewDecoderNumber() {
+	d := NewDecoderNumber(strings.NewReader("\x1e{\"id\":9007199254740993}\n"))
+	var v map[string]interface{}
+	if err := d.Decode(&v); err != nil {
+		fmt.Println(err)
+	}
+	fmt.Printf("%T %s\n", v["id"], v["id"])
+
+	// Output:
+	// json.Number 9007199254740993
+}
+
+func ExampleNewDecoderStrictFields() {
+	d := NewDecoderStrictFields(strings.NewReader("\x1e{\"id\":1}\n\x1e{\"idd\":2}\n"))
+	for {
+		var v struct{ ID int }
+		if err := d.Decode(&v); err == io.EOF {
+			break
+		} else if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		fmt.Println(v.ID)
+	}
+
+	// Output:
+	// 1
+	// json: unknown field "idd"
+}
+
+func ExampleFilter() {
+	in := "\x1e{\"id\":1}\n\x1e{\"id\":2,\"skip\":true}\n\x1e{\"id\":3}\n"
+	kept, total, err := Filter(os.Stdout, strings.NewReader(in), func(raw json.RawMessage) bool {
+		return !bytes.Contains(raw, []byte(`"skip"`))
+	})
+	fmt.Println(kept, total, err)
+
+	// Output:
+	// {"id":1}
+	// {"id":3}
+	// 2 3 <nil>
+}
+
+func ExampleMap() {
+	in := "\x1e{\"id\":1,\"secret\":\"a\"}\n\x1e{\"id\":2}\n\x1enull\n"
+	n, err := Map(os.Stdout, strings.NewReader(in), func(raw json.RawMessage) ([]byte, error) {
+		var v map[string]interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, err
+		}
+		if v == nil {
+			return nil, nil
+		}
+		delete(v, "secret")
+		return json.Marshal(v)
+	})
+	fmt.Println(n, err)
+
+	// Output:
+	// {"id":1}
+	// {"id":2}
+	// 2 <nil>
+}
+
+func ExampleDecoder_Token() {
+	d := NewDecoder(strings.NewReader("\x1e{\"a\":[1,2]}\n\x1etrue\n"))
+	for {
+		t, err := d.Token()
+		if err == io.EOF {
+			break
+		} else if err == ErrRecordEnd {
+			fmt.Println("end")
+			continue
+		} else if err != nil {
+			fmt.Println(err)
+			break
+		}
+		fmt.Printf("%T: %v\n", t, t)
+	}
+
+	// Output:
+	// json.Delim: {
+	// string: a
+	// json.Delim: [
+	// float64: 1
+	// float64: 2
+	// json.Delim: ]
+	// json.Delim: }
+	// end
+	// bool: true
+	// end
+}
+
+func ExampleDecoder_NextDecoder() {
+	d := NewDecoder(strings.NewReader("\x1e{\"id\":9007199254740993}\n"))
+	jd, err := d.NextDecoder()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	jd.UseNumber()
+	var v map[string]interface{}
+	if err := jd.Decode(&v); err != nil {
+		fmt.Println(err)
+	}
+	fmt.Println(v["id"])
+
+	// Output:
+	// 9007199254740993
+}
+
+func ExampleWriteValue() {
+	_ = WriteValue(os.Stdout, map[string]int{"id": 1})
+	_ = WriteValue(os.Stdout, "s")
+
+	// Output:
+	// {"id":1}
+	// "s"
+}
+
+func ExampleRecordScanner() {
+	s := NewRecordScanner(strings.NewReader("\x1e{\"id\":1}\n\x1e 1234 \n\x1e\"s\"\n"))
+	for s.Scan() {
+		fmt.Printf("%s\n", s.Record())
+	}
+	if err := s.Err(); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// {"id":1}
+	// 1234
+	// "s"
+}
+
+func ExampleCopyRecords() {
+	in := "\x1e{\"b\": 2.50, \"a\": 1}\r\n\x1e\x1e [ 1,2 ]\n"
+	n, err := CopyRecords(os.Stdout, strings.NewReader(in))
+	fmt.Println(n, err)
+
+	// Output:
+	// {"b": 2.50, "a": 1}
+	// [ 1,2 ]
+	// 2 <nil>
+}
+
+func ExampleValid() {
+	n, err := Valid(strings.NewReader("\x1e{\"id\":1}\n\x1e[1,2]\n\x1e{\"id\"}\n"))
+	fmt.Println(n, err)
+
+	// Output:
+	// 2 record 2: invalid JSON: "{\"id\"}\n"
+}
+
+func ExampleEncoder_EncodeRaw() {
+	e := NewEncoderT(os.Stdout)
+	_ = e.EncodeRaw(json.RawMessage(`{"b": 2, "a": 1}` + "\n\n"))
+	_ = e.EncodeRaw(json.RawMessage(`[ 1.50 ]`))
+
+	// Output:
+	// {"b": 2, "a": 1}
+	// [ 1.50 ]
+}
+
+func ExampleDecoder_DrainTo() {
+	d := NewDecoder(strings.NewReader("\x1e{\"id\":1}\n\x1e{\"id\":2}\n\x1e{\"id\":3}\n"))
+	var v struct{ ID int }
+	_ = d.Decode(&v)
+	_, _ = d.Peek()
+	fmt.Println("head:", v.ID)
+	n, err := d.DrainTo(os.Stdout)
+	fmt.Println(n, err)
+
+	// Output:
+	// head: 1
+	// {"id":2}
+	// {"id":3}
+	// 20 <nil>
+}
+
+func ExampleLineRecordWriter() {
+	w := &LineRecordWriter{os.Stdout}
+	_, _ = w.Write([]byte(`{"id":1}`))
+	_, _ = w.Write([]byte(`true`))
+
+	// Output:
+	// {"id":1}
+	// true
+}
+
+func ExampleDispatcher() {
+	type Login struct{ User string }
+	type Logout struct {
+		User   string
+		Reason string
+	}
+	var p Dispatcher
+	p.Register("login", func() interface{} { return new(Login) })
+	p.Register("logout", func() interface{} { return new(Logout) })
+
+	d := NewDecoder(strings.NewReader("\x1e{\"type\":\"login\",\"user\":\"a\"}\n" +
+		"\x1e{\"type\":\"crash\"}\n" +
+		"\x1e{\"type\":\"logout\",\"user\":\"a\",\"reason\":\"idle\"}\n"))
+	for {
+		v, err := p.Decode(d)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		fmt.Printf("%T %+v\n", v, v)
+	}
+
+	// Output:
+	// *jsonseq.Login &{User:a}
+	// unknown record type: "crash"
+	// *jsonseq.Logout &{User:a Reason:idle}
+}
+
+func ExampleReindent() {
+	in := "\x1e{ \"id\" : 1,\n \"tags\" : [ \"a\" ] }\n\x1e[1, 2]\n"
+	n, err := Reindent(os.Stdout, strings.NewReader(in), "", "")
+	fmt.Println(n, err)
+	n, err = Reindent(os.Stdout, strings.NewReader(in), "", "  ")
+	fmt.Println(n, err)
+
+	// Output:
+	// {"id":1,"tags":["a"]}
+	// [1,2]
+	// 2 <nil>
+	// {
+	//   "id": 1,
+	//   "tags": [
+	//     "a"
+	//   ]
+	// }
+	// [
+	//   1,
+	//   2
+	// ]
+	// 2 <nil>
+}
+
+func ExampleMarshal() {
+	seq, err := Marshal([]interface{}{map[string]int{"id": 1}, "s", true})
+	if err != nil {
+		fmt.Println(err)
+	}
+	var values []json.RawMessage
+	if err := Unmarshal(seq, &values); err != nil {
+		fmt.Println(err)
+	}
+	fmt.Printf("%q\n", seq)
+	fmt.Printf("%s\n", values)
+
+	// Output:
+	// "\x1e{\"id\":1}\n\x1e\"s\"\n\x1etrue\n"
+	// [{"id":1} "s" true]
+}
+
+func ExampleNewDecoderDiscardFn() {
+	d := NewDecoderDiscardFn(strings.NewReader("\x1e{\"id\":1}\n\x1e{\"id\":2} \"1234\"1234 true\n"), func(extra []byte) {
+		fmt.Printf("discarded: %s\n", extra)
+	})
+	for {
+		var v struct{ ID int }
+		if err := d.Decode(&v); err == io.EOF {
+			break
+		} else if err != nil {
+			fmt.Println(err)
+			break
+		}
+		fmt.Println(v.ID)
+	}
+
+	// Output:
+	// 1
+	// discarded: "1234"1234 true
+	// 2
+}
+
+func ExampleDecoder_Next() {
+	d := NewDecoder(strings.NewReader("\x1e{\"id\":1}\n\x1e[1,2]\n\x1e1234\x1etrue\n"))
+	for d.Next() {
+		fmt.Printf("%s\n", d.RawValue())
+	}
+	if err := d.Err(); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// {"id":1}
+	// [1,2]
+	// invalid record: "1234"
+}
@@ -27,3 +27,48 @@ func FuzzDecEnc(f *testing.F) {
 		}
 	})
 }
+
+func FuzzRecordValue(f *testing.F) {
+	// Bare values ending exactly at the end of the record.
+	for _, s := range []string{"null", "true", "false", "123", "-", "-1", "0", "n", "t", "f",
+		"12.34", "-0.5e+10", "1E-3", "1.", "1e"} {
+		f.Add([]byte("\x1e" + s))
+		f.Add([]byte("\x1e" + s + "\n"))
+		// Non-ASCII space, and invalid UTF-8.
+		f.Add([]byte("\x1e" + s + "\u00a0"))
+		f.Add([]byte("\x1e" + s + "\xff"))
+	}
+	f.Fuzz(func(t *testing.T, b []byte) {
+		v, ok := RecordValue(b)
+		if ok != ValidRecord(b) {
+			t.Errorf("RecordValue and ValidRecord disagree for %q", b)
+		}
+		if ok && ValidValue(b[1:]) != ok {
+			t.Errorf("RecordValue and ValidValue disagree for %q", b)
+		}
+		if ok && len(v) > 0 && wsByte(v[0]) {
+			t.Errorf("value %q has leading whitespace", v)
+		}
+	})
+}
+
+func FuzzStrict(f *testing.F) {
+	// Adjacent values of each type.
+	for _, s := range []string{`"1234"1234`, `"a""b"`, `{}{}`, `{"id":1}[1]`, `[1][2]`, `[]"s"`, `{}true `, `1 2 `, `null null `} {
+		f.Add([]byte("\x1e" + s + "\n"))
+	}
+	f.Fuzz(func(t *testing.T, b []byte) {
+		d := NewDecoder(bytes.NewReader(b))
+		d.SetStrict(true)
+		for {
+			var v interface{}
+			if err := d.Decode(&v); err != nil {
+				return
+			}
+			raw := d.Raw()
+			if err := checkSingleValue(bytes.TrimLeft(raw, "\x1e")); err != nil {
+				t.Errorf("strict record %q: %v", raw, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,31 @@
+//go:build go1.18
+
+package jsonseq
+
+import (
+	"fmt"
+	"io"
+)
+
+// DecodeValue decodes the next record from d into a new T.
+func DecodeValue[T any](d *Decoder) (T, error) {
+	var v T
+	err := d.Decode(&v)
+	return v, err
+}
+
+// Collect decodes every record from r into a slice of T. It returns the values
+// decoded before the first error, and the error annotated with its record index.
+func Collect[T any](r io.Reader) ([]T, error) {
+	d := NewDecoder(r)
+	var vs []T
+	for i := 0; ; i++ {
+		v, err := DecodeValue[T](d)
+		if err == io.EOF {
+			return vs, nil
+		} else if err != nil {
+			return vs, fmt.Errorf("record %d: %w", i, err)
+		}
+		vs = append(vs, v)
+	}
+}
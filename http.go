@@ -0,0 +1,83 @@
+package jsonseq
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ServeSeq writes each value received from values to w as a record, until
+// values is closed or an error occurs. The Content-Type header is set to
+// ContentType, and if w is an http.Flusher, each record is flushed as it is
+// written, for progressive delivery.
+func ServeSeq(w http.ResponseWriter, values <-chan interface{}) error {
+	w.Header().Set("Content-Type", ContentType)
+	e := NewEncoderT(w)
+	e.SetAutoFlush(true)
+	for v := range values {
+		if err := e.Encode(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AcceptSeq sets the Accept header of req to ContentType.
+func AcceptSeq(req *http.Request) {
+	req.Header.Set("Accept", ContentType)
+}
+
+// IsSeqResponse reports whether the Content-Type of resp is ContentType,
+// ignoring any parameters.
+func IsSeqResponse(resp *http.Response) bool {
+	return MatchMediaType(resp.Header.Get("Content-Type"))
+}
+
+// MediaType returns the base media type of JSON text sequences, ContentType,
+// without parameters.
+func MediaType() string {
+	return ContentType
+}
+
+// MatchMediaType reports whether the media type header, such as a Content-Type
+// or a single Accept entry, is ContentType, ignoring case and any parameters,
+// like a charset.
+func MatchMediaType(header string) bool {
+	mt, _, err := mime.ParseMediaType(header)
+	return err == nil && mt == ContentType
+}
+
+// Detect reports whether b, such as the first bytes of a blob, looks like a
+// JSON text sequence. It is a heuristic, complementing http.DetectContentType,
+// with the following criteria: after optional whitespace, b begins with an RS,
+// and the first record value begins like a JSON value. If b contains another
+// RS, then the first record must also be valid per RecordValue. Otherwise the
+// record may be cut short, as when sniffing a prefix.
+func Detect(b []byte) bool {
+	b = bytes.TrimLeftFunc(b, wsRune)
+	if len(b) == 0 || b[0] != rs {
+		return false
+	}
+	b = bytes.TrimLeft(b, "\x1e")
+	i := bytes.IndexByte(b, rs)
+	if i >= 0 {
+		if !ValidRecord(append([]byte{rs}, b[:i]...)) {
+			return false
+		}
+		b = b[:i]
+	}
+	v := bytes.TrimLeftFunc(b, wsRune)
+	return len(v) > 0 && strings.IndexByte(`{["-0123456789tfn`, v[0]) >= 0
+}
+
+// NewResponseDecoder returns a Decoder reading from the body of resp, or an
+// error if the Content-Type of resp is not ContentType. The caller remains
+// responsible for closing the body.
+func NewResponseDecoder(resp *http.Response) (*Decoder, error) {
+	if ct := resp.Header.Get("Content-Type"); !MatchMediaType(ct) {
+		return nil, fmt.Errorf("unexpected Content-Type %q: expected %q", ct, ContentType)
+	}
+	return NewDecoder(resp.Body), nil
+}
@@ -0,0 +1,114 @@
+package jsonseq
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServeSeq(t *testing.T) {
+	values := make(chan interface{}, 2)
+	values <- map[string]int{"id": 1}
+	values <- "Test"
+	close(values)
+
+	rec := httptest.NewRecorder()
+	if err := ServeSeq(rec, values); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.Header().Get("Content-Type"); got != ContentType {
+		t.Errorf("got Content-Type %q but want %q", got, ContentType)
+	}
+	if !rec.Flushed {
+		t.Error("expected response to be flushed")
+	}
+	if got, want := rec.Body.String(), "\x1e{\"id\":1}\n\x1e\"Test\"\n"; got != want {
+		t.Errorf("got body %q but want %q", got, want)
+	}
+}
+
+func TestIsSeqResponse(t *testing.T) {
+	for _, tt := range []struct {
+		contentType string
+		want        bool
+	}{
+		{"application/json-seq", true},
+		{"application/json-seq; charset=utf-8", true},
+		{"Application/JSON-Seq", true},
+		{"application/json", false},
+		{"text/html; charset=utf-8", false},
+		{"", false},
+	} {
+		resp := &http.Response{Header: http.Header{}}
+		resp.Header.Set("Content-Type", tt.contentType)
+		if got := IsSeqResponse(resp); got != tt.want {
+			t.Errorf("%q: got %t but want %t", tt.contentType, got, tt.want)
+		}
+	}
+}
+
+func TestNewResponseDecoder(t *testing.T) {
+	resp := &http.Response{
+		Header: http.Header{"Content-Type": {"text/html"}},
+		Body:   io.NopCloser(strings.NewReader("<html></html>")),
+	}
+	if _, err := NewResponseDecoder(resp); err == nil {
+		t.Error("expected error for text/html response")
+	}
+
+	resp = &http.Response{
+		Header: http.Header{"Content-Type": {ContentType + "; charset=utf-8"}},
+		Body:   io.NopCloser(strings.NewReader("\x1e{\"id\":1}\n")),
+	}
+	d, err := NewResponseDecoder(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v struct{ ID int }
+	if err := d.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	if v.ID != 1 {
+		t.Errorf("got id %d but want 1", v.ID)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	for _, tt := range []struct {
+		b    string
+		want bool
+	}{
+		{"\x1e{\"id\":1}\n\x1e{\"id\":2}\n", true},
+		{" \n\x1e\x1e [1,2", true},
+		{"\x1etrue\n", true},
+		{"\x1etrue\x1efalse\n", false},
+		{"\x1e<html>", false},
+		{"\x1e", false},
+		{"{\"id\":1}\n", false},
+		{"", false},
+	} {
+		if got := Detect([]byte(tt.b)); got != tt.want {
+			t.Errorf("%q: got %t but want %t", tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestMatchMediaType(t *testing.T) {
+	for _, tt := range []struct {
+		header string
+		want   bool
+	}{
+		{MediaType(), true},
+		{"application/json-seq; charset=utf-8", true},
+		{"APPLICATION/JSON-SEQ ; charset=\"utf-8\"", true},
+		{"application/json", false},
+		{"application/json-seq; charset", false},
+		{"", false},
+	} {
+		if got := MatchMediaType(tt.header); got != tt.want {
+			t.Errorf("%q: got %t but want %t", tt.header, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package jsonseq
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// An Index holds the byte offsets of the records of a seekable sequence, for
+// random access.
+type Index struct {
+	offsets []int64 // Offset of each record's RS.
+	ends    []int64 // Offset following each record.
+}
+
+// BuildIndex reads records from r, starting at its current position, and
+// returns an Index of their offsets. Offsets are relative to the start of r.
+// Invalid records are returned as errors, annotated with the record index.
+func BuildIndex(r io.ReadSeeker) (*Index, error) {
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	d := NewDecoder(r)
+	ix := &Index{}
+	for i := 0; ; i++ {
+		if _, err := d.next(); err == io.EOF {
+			return ix, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("record %d: %w", i, err)
+		}
+		ix.offsets = append(ix.offsets, start+d.InputOffset())
+		ix.ends = append(ix.ends, start+d.BytesRead())
+	}
+}
+
+// Len returns the number of records in the index.
+func (ix *Index) Len() int {
+	return len(ix.offsets)
+}
+
+// Offset returns the byte offset of the RS beginning record n.
+func (ix *Index) Offset(n int) int64 {
+	return ix.offsets[n]
+}
+
+// Record seeks r to record n, and returns a copy of its value bytes, without
+// trailing whitespace. The position of r afterwards is unspecified.
+func (ix *Index) Record(r io.ReadSeeker, n int) (json.RawMessage, error) {
+	if n < 0 || n >= len(ix.offsets) {
+		return nil, fmt.Errorf("record %d out of range [0, %d)", n, len(ix.offsets))
+	}
+	if _, err := r.Seek(ix.offsets[n], io.SeekStart); err != nil {
+		return nil, err
+	}
+	raw := make([]byte, ix.ends[n]-ix.offsets[n])
+	if _, err := io.ReadFull(r, raw); err != nil {
+		return nil, fmt.Errorf("record %d: %w", n, err)
+	}
+	b, ok := RecordValue(raw)
+	if !ok {
+		return nil, fmt.Errorf("record %d: %w", n, &InvalidRecordError{Record: b})
+	}
+	return bytes.TrimRightFunc(b, wsRune), nil
+}
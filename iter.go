@@ -0,0 +1,32 @@
+//go:build go1.23
+
+package jsonseq
+
+import (
+	"encoding/json"
+	"io"
+	"iter"
+)
+
+// All returns an iterator over the value bytes of each remaining record, without
+// trailing whitespace. Invalid records are yielded with an error, and iteration
+// continues until the end of the input, or a scanning error. Each yielded value
+// is a copy, and remains valid after iteration advances.
+func (d *Decoder) All() iter.Seq2[json.RawMessage, error] {
+	return func(yield func(json.RawMessage, error) bool) {
+		for {
+			b, err := d.nextValue()
+			if err == io.EOF {
+				return
+			} else if err != nil {
+				if !yield(nil, err) || !d.More() {
+					return
+				}
+				continue
+			}
+			if !yield(append(json.RawMessage(nil), b...), nil) {
+				return
+			}
+		}
+	}
+}
@@ -18,7 +18,9 @@ package jsonseq
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 )
@@ -29,17 +31,39 @@ const ContentType = `application/json-seq`
 
 const digitSet = "1234567980"
 
+// numberSet holds the bytes which may follow the first digit of a number,
+// including fractions and exponents.
+const numberSet = digitSet + ".eE+-"
+
+// Framing bytes of a JSON text sequence record.
 const (
-	rs = 0x1E
-	lf = 0x0A
+	RS byte = 0x1E // Record separator, beginning each record.
+	LF byte = 0x0A // Line feed, ending each record.
+)
+
+const (
+	rs = RS
+	lf = LF
 	sp = 0x20
 	tb = 0x09
 	cr = 0x0D
 )
 
+// Marker bytes shared by writes, to avoid allocating.
+var (
+	rsBytes = [1]byte{rs}
+	lfBytes = [1]byte{lf}
+)
+
 // whitespace characters defined in https://tools.ietf.org/html/rfc7159#section-2.
 var wsSet = []byte{sp, tb, lf, cr}
 
+// IsJSONWhitespace reports whether b is one of the whitespace bytes permitted
+// around JSON values: space, tab, line feed, or carriage return.
+func IsJSONWhitespace(b byte) bool {
+	return wsByte(b)
+}
+
 func wsByte(b byte) bool {
 	return bytes.IndexByte(wsSet, b) >= 0
 }
@@ -51,7 +75,7 @@ func wsRune(r rune) bool {
 // WriteRecord writes a JSON text sequence record with beginning
 // (RS) and end (LF) marker bytes.
 func WriteRecord(w io.Writer, json []byte) error {
-	_, err := w.Write([]byte{rs})
+	_, err := w.Write(rsBytes[:])
 	if err != nil {
 		return err
 	}
@@ -59,7 +83,98 @@ func WriteRecord(w io.Writer, json []byte) error {
 	if err != nil {
 		return err
 	}
-	_, err = w.Write([]byte{lf})
+	_, err = w.Write(lfBytes[:])
+	return err
+}
+
+// WriteRecordCanonical is like WriteRecord, but first trims any leading and
+// trailing whitespace from json, so that the record is written in canonical
+// form, with a single RS immediately preceding the value and a single LF
+// immediately following it.
+func WriteRecordCanonical(w io.Writer, json []byte) error {
+	return WriteRecord(w, bytes.TrimFunc(json, wsRune))
+}
+
+// WriteRecordString is like WriteRecord, but writes the string json with
+// io.WriteString, which avoids copying it when w implements io.StringWriter.
+func WriteRecordString(w io.Writer, json string) error {
+	_, err := w.Write(rsBytes[:])
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, json)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(lfBytes[:])
+	return err
+}
+
+// WriteValue writes the JSON encoding of v, per json.Marshal, as a record with
+// WriteRecord. Marshaling errors are returned unchanged.
+func WriteValue(w io.Writer, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return WriteRecord(w, b)
+}
+
+// WriteRecordN is like WriteRecord, but also returns the number of bytes
+// written, including the RS and LF marker bytes.
+func WriteRecordN(w io.Writer, json []byte) (int, error) {
+	n, err := w.Write(rsBytes[:])
+	if err != nil {
+		return n, err
+	}
+	m, err := w.Write(json)
+	n += m
+	if err != nil {
+		return n, err
+	}
+	m, err = w.Write(lfBytes[:])
+	return n + m, err
+}
+
+// AppendRecord appends a JSON text sequence record, with beginning (RS) and
+// end (LF) marker bytes, to dst and returns the extended buffer.
+func AppendRecord(dst, json []byte) []byte {
+	dst = append(dst, rs)
+	dst = append(dst, json...)
+	return append(dst, lf)
+}
+
+// WriteRecords writes each of records as a JSON text sequence record, as with
+// WriteRecord. Unless w is already buffered, the records are written with a
+// single call to Write. The returned error is annotated with the index of the
+// first record which was not completely written.
+func WriteRecords(w io.Writer, records ...[]byte) error {
+	if _, ok := w.(io.ByteWriter); ok {
+		// Already buffered, e.g. *bufio.Writer or *bytes.Buffer.
+		for i, r := range records {
+			if err := WriteRecord(w, r); err != nil {
+				return fmt.Errorf("record %d: %w", i, err)
+			}
+		}
+		return nil
+	}
+	size := 0
+	for _, r := range records {
+		size += len(r) + 2
+	}
+	buf := make([]byte, 0, size)
+	for _, r := range records {
+		buf = AppendRecord(buf, r)
+	}
+	n, err := w.Write(buf)
+	if err != nil {
+		// Find the record which was cut short.
+		for i, r := range records {
+			if n -= len(r) + 2; n < 0 {
+				return fmt.Errorf("record %d: %w", i, err)
+			}
+		}
+	}
 	return err
 }
 
@@ -73,7 +188,7 @@ type RecordWriter struct {
 
 // Write prefixes every written record with an ASCII record separator.
 func (w *RecordWriter) Write(record []byte) (int, error) {
-	_, err := w.Writer.Write([]byte{rs})
+	_, err := w.Writer.Write(rsBytes[:])
 	if err != nil {
 		return -1, err
 	}
@@ -81,9 +196,39 @@ func (w *RecordWriter) Write(record []byte) (int, error) {
 	return n + 1, err
 }
 
+// Reset resets w to write to nw.
+func (w *RecordWriter) Reset(nw io.Writer) {
+	w.Writer = nw
+}
+
+// A LineRecordWriter frames each Write as a complete record, with both the
+// beginning (RS) and end (LF) marker bytes.
+//
+// Callers must only call Write once for each value, with the entire value.
+type LineRecordWriter struct {
+	io.Writer
+}
+
+// Write writes record as a JSON text sequence record, as with WriteRecord. On
+// success, it returns len(record), excluding framing.
+func (w *LineRecordWriter) Write(record []byte) (int, error) {
+	if err := WriteRecord(w.Writer, record); err != nil {
+		return 0, err
+	}
+	return len(record), nil
+}
+
+// Reset resets w to write to nw.
+func (w *LineRecordWriter) Reset(nw io.Writer) {
+	w.Writer = nw
+}
+
 // NewEncoder returns a standard library json.Encoder that writes a JSON text sequence to w.
 //
 // The Encoder calls Write just once for each value and always with a trailing line feed.
+// Indentation set with SetIndent only adds whitespace within each value, so
+// indented records remain valid, with the RS preceding the value and a single
+// trailing line feed.
 func NewEncoder(w io.Writer) *json.Encoder {
 	return json.NewEncoder(&RecordWriter{w})
 }
@@ -94,52 +239,727 @@ func NewEncoder(w io.Writer) *json.Encoder {
 // valid. This disqualifies parsers which assume a single value (e.g. json.Unmarshal).
 type Decode func(b []byte, v interface{}) error
 
+// An InvalidRecordError reports a record which is not valid according to
+// RecordValue.
+type InvalidRecordError struct {
+	Record []byte // The invalid record, as returned by RecordValue.
+}
+
+func (e *InvalidRecordError) Error() string {
+	return fmt.Sprintf("invalid record: %q", string(e.Record))
+}
+
+// ErrPartialRecord is returned when the final record of the input does not end
+// with an LF, if enabled by Decoder.SetErrOnPartial. This distinguishes a
+// truncated stream from a clean end.
+var ErrPartialRecord = errors.New("partial final record")
+
+// A TrailingDataError reports data following the first value of a record.
+type TrailingDataError struct {
+	Data []byte // The trailing data.
+}
+
+func (e *TrailingDataError) Error() string {
+	return fmt.Sprintf("trailing data after value: %q", string(e.Data))
+}
+
+// checkSingleValue returns an error unless b holds a single JSON value,
+// optionally followed by whitespace.
+func checkSingleValue(b []byte) error {
+	d := json.NewDecoder(bytes.NewReader(b))
+	var raw json.RawMessage
+	if err := d.Decode(&raw); err != nil {
+		return err
+	}
+	if extra := bytes.TrimLeftFunc(b[d.InputOffset():], wsRune); len(extra) > 0 {
+		return &TrailingDataError{Data: append([]byte(nil), extra...)}
+	}
+	return nil
+}
+
 // A Decoder reads and decodes JSON text sequence records from an input stream.
 type Decoder struct {
+	r  *ctxReader
 	s  *bufio.Scanner
 	fn Decode
+
+	max int    // Maximum record size.
+	buf []byte // Initial scanner buffer, reused by Reset.
+
+	split bufio.SplitFunc
+	value func([]byte) ([]byte, bool) // Like RecordValue.
+
+	base   int64  // Offset of the input within its stream, for Position.
+	off    int64  // Bytes consumed by the scanner.
+	tokOff int64  // Offset of the scanned record.
+	tokEnd int64  // Offset following the scanned record.
+	tokEOF bool   // Whether the scanned record ended at EOF.
+	rest   []byte // Bytes read by the scanner following the scanned record.
+	recOff int64  // Offset of the last returned record.
+	recEnd int64  // Offset following the last returned record.
+	count  int    // Records successfully decoded.
+	read   int    // Records read, including invalid records.
+	limit  int    // Maximum count, if positive.
+
+	raw []byte        // The last returned record, including framing.
+	cur []byte        // The current value, for Next.
+	err error         // The first error from Next.
+	tok *json.Decoder // Tokenizes the current record, for Token.
+
+	// Whether the scanner holds a record which has not been returned yet.
+	buffered bool
+
+	skipInvalid  bool
+	skipped      [][]byte
+	skipPreamble bool
+	skipEmpty    bool
+
+	singleValue bool
+	strict      bool
+	requireLF   bool
+	partial     bool
+
+	tee io.Writer
+
+	onRecord  func(size int)
+	onInvalid func(raw []byte)
+	invalid   bool // Whether the buffered record is invalid.
 }
 
 // NewDecoder creates a new Decoder backed by the standard library's encoding/json
 // Decoder. Any extra trailing data is discarded.
 func NewDecoder(r io.Reader) *Decoder {
+	return NewDecoderFn(r, decodeFirst)
+}
+
+// decodeFirst is a Decode function which decodes the first value, and discards
+// any remaining data.
+func decodeFirst(b []byte, v interface{}) error {
+	return json.NewDecoder(bytes.NewReader(b)).Decode(v)
+}
+
+// NewDecoderNumber is like NewDecoder, but decodes numbers into an interface{}
+// as a json.Number rather than a float64, per json.Decoder.UseNumber.
+func NewDecoderNumber(r io.Reader) *Decoder {
+	return NewDecoderFn(r, DecodeNumber)
+}
+
+// DecodeNumber is a Decode function like the default, but which decodes numbers
+// into an interface{} as a json.Number, for use with NewDecoderFn.
+func DecodeNumber(b []byte, v interface{}) error {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	return d.Decode(v)
+}
+
+// NewDecoderStrictFields is like NewDecoder, but returns an error when a record
+// holds an object key which does not match a field of the destination struct,
+// per json.Decoder.DisallowUnknownFields.
+func NewDecoderStrictFields(r io.Reader) *Decoder {
+	return NewDecoderFn(r, DecodeStrictFields)
+}
+
+// DecodeStrictFields is a Decode function like the default, but which rejects
+// unknown object keys, for use with NewDecoderFn.
+func DecodeStrictFields(b []byte, v interface{}) error {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.DisallowUnknownFields()
+	return d.Decode(v)
+}
+
+// NewDecoderDiscardFn is like NewDecoder, but calls onDiscard with any data,
+// other than whitespace, which follows the first value of a record and is
+// discarded. The data is only valid until onDiscard returns.
+func NewDecoderDiscardFn(r io.Reader, onDiscard func(extra []byte)) *Decoder {
 	return NewDecoderFn(r, func(b []byte, v interface{}) error {
-		// Decode the first value, and discard any remaining data.
-		return json.NewDecoder(bytes.NewReader(b)).Decode(v)
+		d := json.NewDecoder(bytes.NewReader(b))
+		if err := d.Decode(v); err != nil {
+			return err
+		}
+		if extra := bytes.TrimFunc(b[d.InputOffset():], wsRune); len(extra) > 0 {
+			onDiscard(extra)
+		}
+		return nil
 	})
 }
 
 // NewDecoderFn creates a new Decoder backed by a custom Decode function.
 func NewDecoderFn(r io.Reader, fn Decode) *Decoder {
-	s := bufio.NewScanner(r)
-	s.Split(ScanRecord)
-	return &Decoder{
-		s:  s,
-		fn: fn,
+	d := &Decoder{r: &ctxReader{}}
+	d.init(fn)
+	d.Reset(r)
+	return d
+}
+
+// init sets the default settings of a Decoder backed by fn.
+func (d *Decoder) init(fn Decode) {
+	d.fn = fn
+	d.max = bufio.MaxScanTokenSize
+	d.split = ScanRecord
+	d.value = RecordValue
+}
+
+// Reset discards any buffered data and state, and resets d to read from r,
+// while retaining its Decode function and settings. This permits reusing a
+// Decoder, and its buffer, for multiple streams.
+func (d *Decoder) Reset(r io.Reader) {
+	*d.r = ctxReader{r: r, buf: d.r.buf}
+	if d.buf == nil {
+		d.buf = make([]byte, 4096)
+	}
+	d.s = bufio.NewScanner(d.r)
+	d.s.Split(d.scan)
+	d.buffer()
+	d.base, d.off, d.tokOff, d.tokEnd, d.recOff, d.recEnd = 0, 0, 0, 0, 0, 0
+	d.count, d.read = 0, 0
+	d.buffered = false
+	d.raw, d.cur, d.err = nil, nil, nil
+	d.rest = nil
+	d.tok = nil
+	d.invalid = false
+	d.skipped = nil
+}
+
+// scan is the scanner's bufio.SplitFunc. It wraps split to track offsets.
+func (d *Decoder) scan(data []byte, atEOF bool) (int, []byte, error) {
+	advance, token, err := d.split(data, atEOF)
+	if token != nil {
+		// The token is a sub-slice of data, so the difference in capacity is
+		// its position.
+		d.tokOff = d.off + int64(cap(data)-cap(token))
+		d.tokEnd = d.off + int64(advance)
+		d.tokEOF = atEOF && advance == len(data)
+	}
+	d.off += int64(advance)
+	d.rest = data[advance:]
+	return advance, token, err
+}
+
+// SetSkipInvalid controls whether invalid records are skipped, rather than
+// returned as errors. Skipped records are retained for inspection via Skipped.
+func (d *Decoder) SetSkipInvalid(skip bool) {
+	d.skipInvalid = skip
+}
+
+// SetStrictSingleValue controls whether records holding more than a single
+// value are rejected with a *TrailingDataError, rather than the trailing data
+// being discarded, which is the default.
+func (d *Decoder) SetStrictSingleValue(strict bool) {
+	d.singleValue = strict
+}
+
+// SetStrict controls whether records are checked against every rule of RFC 7464,
+// rather than only for invalid framing. Violations are reported as a
+// *StrictError naming the rule. Strict mode assumes the default framing.
+func (d *Decoder) SetStrict(strict bool) {
+	d.strict = strict
+}
+
+// SetTee sets a writer to which the raw bytes of each record, including
+// framing, are copied as they are read, before being decoded. Invalid records
+// are not copied. A failed write is returned by the read which triggered it.
+// A nil w disables copying.
+func (d *Decoder) SetTee(w io.Writer) {
+	d.tee = w
+}
+
+// SetRequireLF controls whether records which do not end with an LF are
+// rejected with a *StrictError for the rule ErrNoLF, without enforcing the other
+// rules of SetStrict.
+func (d *Decoder) SetRequireLF(require bool) {
+	d.requireLF = require
+}
+
+// SetErrOnPartial controls whether a final record which does not end with an LF
+// is rejected with an error wrapping ErrPartialRecord, regardless of
+// SetSkipInvalid, rather than being decoded if valid, which is the default.
+func (d *Decoder) SetErrOnPartial(on bool) {
+	d.partial = on
+}
+
+// SetSkipPreamble controls whether any bytes preceding the first RS of the
+// input, such as a UTF-8 byte order mark, are discarded, rather than returned
+// as an invalid record. Only the head of the input is affected.
+func (d *Decoder) SetSkipPreamble(skip bool) {
+	d.skipPreamble = skip
+}
+
+// OnRecord registers fn to be called after each record is returned
+// successfully, whether decoded by Decode or read raw, as by DecodeRaw or Next,
+// with the size of the record in bytes, including framing.
+func (d *Decoder) OnRecord(fn func(size int)) {
+	d.onRecord = fn
+}
+
+// OnInvalid registers fn to be called with the raw bytes of each invalid record
+// as it is consumed or skipped, including framing. The bytes are only valid
+// until fn returns.
+func (d *Decoder) OnInvalid(fn func(raw []byte)) {
+	d.onInvalid = fn
+}
+
+// SetSkipEmpty controls whether empty records, holding only whitespace, such
+// as keep-alive heartbeats, are silently discarded, rather than returned as
+// invalid records, which is the default. Discarded records are not retained by
+// Skipped.
+func (d *Decoder) SetSkipEmpty(skip bool) {
+	d.skipEmpty = skip
+}
+
+// Skipped returns the raw bytes of each invalid record skipped so far, including
+// framing.
+func (d *Decoder) Skipped() [][]byte {
+	return d.skipped
+}
+
+// InputOffset returns the input stream byte offset where the most recently
+// returned record began, counting its leading RS.
+func (d *Decoder) InputOffset() int64 {
+	return d.recOff
+}
+
+// Raw returns the complete bytes of the most recently returned record, as
+// scanned, including framing and any trailing whitespace. The bytes are only
+// valid until the next record is scanned, including by More or Peek.
+func (d *Decoder) Raw() []byte {
+	return d.raw
+}
+
+// Count returns the number of records successfully decoded or otherwise
+// returned, as by DecodeRaw or Next.
+func (d *Decoder) Count() int {
+	return d.count
+}
+
+// BytesRead returns the number of input bytes consumed through the end of the
+// most recently returned record, including framing and any skipped records.
+func (d *Decoder) BytesRead() int64 {
+	return d.recEnd
+}
+
+// Buffered returns a reader over the input which has been read from the
+// underlying reader, but not yet returned by the Decoder, including any record
+// buffered by Peek or More. The reader is only valid until the next call to the
+// Decoder. Together with the underlying reader, it permits handing the rest of
+// the input to another parser.
+func (d *Decoder) Buffered() io.Reader {
+	if d.buffered {
+		return io.MultiReader(bytes.NewReader(d.s.Bytes()), bytes.NewReader(d.rest))
 	}
+	return bytes.NewReader(d.rest)
+}
+
+// Position returns the byte offset just past the most recently returned record,
+// which is the boundary of the next record, for resuming with NewDecoderAt.
+// Unlike BytesRead, the offset includes that passed to NewDecoderAt.
+func (d *Decoder) Position() int64 {
+	return d.base + d.recEnd
+}
+
+// NewDecoderAt returns a Decoder like NewDecoder, which resumes reading r from
+// offset, such as a previous Position. It returns an error if offset is not the
+// end of r or the beginning of a record, with an RS.
+func NewDecoderAt(r io.ReadSeeker, offset int64) (*Decoder, error) {
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	var b [1]byte
+	switch _, err := io.ReadFull(r, b[:]); {
+	case err == io.EOF:
+		// Seeking past the end is permitted, so check the size.
+		size, err := r.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, err
+		}
+		if offset != size {
+			return nil, fmt.Errorf("offset %d is past the end of the input at %d", offset, size)
+		}
+	case err != nil:
+		return nil, err
+	case b[0] != rs:
+		return nil, fmt.Errorf("offset %d is not a record boundary: found %q", offset, b[0])
+	default:
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+	d := NewDecoder(r)
+	d.base = offset
+	return d, nil
+}
+
+// SetLimit sets a maximum number of records to return, as counted by Count,
+// after which Decode and every other reading method behave as at the end of the
+// input, without reading further. The underlying reader is not drained,
+// although input may have been buffered beyond the last decoded record. A limit
+// of zero, the default, is unlimited.
+func (d *Decoder) SetLimit(n int) {
+	d.limit = n
+}
+
+// SetMaxRecordSize sets the maximum size in bytes of a single record, including
+// framing. The default is bufio.MaxScanTokenSize (64KB). Decoding a larger
+// record fails with an error wrapping bufio.ErrTooLong.
+//
+// SetMaxRecordSize panics if called after decoding has started.
+func (d *Decoder) SetMaxRecordSize(n int) {
+	d.max = n
+	d.buffer()
+}
+
+// buffer sets the scanner's buffer and maximum size, per d.max.
+func (d *Decoder) buffer() {
+	// Leave room to read the byte which ends the record.
+	size := d.max + 1
+	buf := d.buf
+	if size < cap(buf) {
+		// The scanner only checks the maximum when growing its buffer.
+		buf = buf[:0:size]
+	}
+	d.s.Buffer(buf, size)
 }
 
 // Decode scans the next record, or returns an error.
 // The Decoder remains valid until io.EOF is returned.
+//
+// As an escape hatch, if v is an io.Writer, such as a file or hash, then the
+// value bytes of the record, without trailing whitespace, are written to it
+// rather than decoded.
 func (d *Decoder) Decode(v interface{}) error {
-	if !d.s.Scan() {
+	return d.DecodeContext(context.Background(), v)
+}
+
+// DecodeContext is like Decode, but returns early with the context's error if
+// ctx is done before the next record is read, including while blocked reading
+// from the underlying io.Reader. A Decoder remains valid after cancellation
+// only if no read was interrupted.
+func (d *Decoder) DecodeContext(ctx context.Context, v interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	d.r.ctx = ctx
+	defer func() { d.r.ctx = nil }()
+
+	b, err := d.consume()
+	if err != nil {
+		return err
+	}
+	if d.singleValue {
+		if err := checkSingleValue(b); err != nil {
+			return err
+		}
+	}
+	if w, ok := v.(io.Writer); ok {
+		if _, err := w.Write(bytes.TrimRightFunc(b, wsRune)); err != nil {
+			return err
+		}
+	} else if err := d.fn(b, v); err != nil {
+		return err
+	}
+	d.record()
+	return nil
+}
+
+// DecodeNumbered is like Decode, but also returns the 1-based number of the
+// record read, counting every record of the input, including invalid records,
+// whether skipped or returned as errors. If no record was read, such as at the
+// end of the input, it returns zero.
+func (d *Decoder) DecodeNumbered(v interface{}) (int, error) {
+	read := d.read
+	err := d.Decode(v)
+	if d.read == read {
+		return 0, err
+	}
+	return d.read, err
+}
+
+// DecodeRaw returns a copy of the value bytes of the next record, without
+// trailing whitespace. Unlike decoding into a json.RawMessage with Decode, the
+// value is only checked by RecordValue, and not parsed.
+func (d *Decoder) DecodeRaw() (json.RawMessage, error) {
+	b, err := d.nextValue()
+	if err != nil {
+		return nil, err
+	}
+	return append(json.RawMessage(nil), b...), nil
+}
+
+// DecodeEach calls fn with the value bytes of each remaining record, without
+// trailing whitespace, until the end of the input. The bytes are only valid
+// until fn returns. Errors from scanning and from fn are returned as is.
+func (d *Decoder) DecodeEach(fn func(raw json.RawMessage) error) error {
+	for {
+		b, err := d.nextValue()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := fn(b); err != nil {
+			return err
+		}
+	}
+}
+
+// Peek returns the value bytes of the next record, without trailing whitespace,
+// and without consuming it, so that the next call to Decode decodes the same
+// record. The bytes are only valid until the record is consumed. An invalid
+// record remains buffered, and is returned as an error by both Peek and the next
+// Decode. Peek returns io.EOF when no records remain.
+func (d *Decoder) Peek() (json.RawMessage, error) {
+	b, err := d.peek()
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimRightFunc(b, wsRune), nil
+}
+
+// More reports whether there is another record in the input. The record is
+// scanned and buffered, so that the next call to Decode returns it, valid or
+// not. Records which Decode would skip, per SetSkipInvalid, SetSkipEmpty, or
+// SetSkipPreamble, are skipped first. More returns false at the end of the
+// input, once the limit set by SetLimit is reached, or after a scanning error,
+// which is reported by Err.
+func (d *Decoder) More() bool {
+	_, _ = d.peek()
+	return d.buffered
+}
+
+// scanNext scans the next record if necessary, and reports whether one is
+// buffered, without checking or skipping it.
+func (d *Decoder) scanNext() bool {
+	if d.limit > 0 && d.count >= d.limit {
+		return false
+	}
+	if !d.buffered {
+		d.buffered = d.s.Scan()
+	}
+	return d.buffered
+}
+
+// Resync discards any buffered record, such as an invalid record returned as an
+// error by Peek, and scans ahead to the next record, which is buffered for the
+// next call to Decode. Decode itself always consumes the record it reads, even
+// if invalid. A discarded record is still numbered, as by DecodeNumbered, and
+// passed to the OnInvalid callback if invalid. Resync returns io.EOF if no
+// records remain.
+func (d *Decoder) Resync() error {
+	if d.buffered {
+		d.buffered = false
+		d.recOff, d.recEnd = d.tokOff, d.tokEnd
+		d.read++
+		if d.invalid && d.onInvalid != nil {
+			d.onInvalid(d.s.Bytes())
+		}
+		d.invalid = false
+	}
+	if !d.More() {
 		if err := d.s.Err(); err != nil {
 			return err
 		}
 		return io.EOF
 	}
-	b := d.s.Bytes()
+	return nil
+}
 
-	b, ok := RecordValue(b)
+// Next advances to the next record, whose value is then available via
+// RawValue, for iterating without comparing errors to io.EOF:
+//
+//	for d.Next() {
+//		process(d.RawValue())
+//	}
+//	if err := d.Err(); err != nil {
+//		...
+//	}
+//
+// Next returns false at the end of the input, or after an error, including an
+// invalid record, which is reported by Err.
+func (d *Decoder) Next() bool {
+	d.cur = nil
+	if d.err != nil {
+		return false
+	}
+	b, err := d.nextValue()
+	if err != nil {
+		if err != io.EOF {
+			d.err = err
+		}
+		return false
+	}
+	d.cur = b
+	return true
+}
+
+// RawValue returns the value bytes of the current record from Next, without
+// trailing whitespace. The bytes are only valid until the next record is read.
+func (d *Decoder) RawValue() json.RawMessage {
+	return d.cur
+}
+
+// Err returns the first non-EOF error encountered by Next, or otherwise while
+// scanning.
+func (d *Decoder) Err() error {
+	if d.err != nil {
+		return d.err
+	}
+	return d.s.Err()
+}
+
+// next scans the next record and returns its value bytes, which are only valid
+// until the following scan. The record is counted, as by record.
+func (d *Decoder) next() ([]byte, error) {
+	b, err := d.consume()
+	if err != nil {
+		return nil, err
+	}
+	d.record()
+	return b, nil
+}
+
+// record counts the most recently consumed record as returned successfully.
+func (d *Decoder) record() {
+	d.count++
+	if d.onRecord != nil {
+		d.onRecord(len(d.raw))
+	}
+}
+
+// consume is like next, but leaves counting the record to the caller, for when
+// decoding it may yet fail.
+func (d *Decoder) consume() ([]byte, error) {
+	b, err := d.peek()
+	if d.buffered {
+		// Consume the record, even if invalid.
+		d.buffered = false
+		d.recOff, d.recEnd = d.tokOff, d.tokEnd
+		d.raw = d.s.Bytes()
+		d.tok = nil
+		d.read++
+		if d.invalid && d.onInvalid != nil {
+			d.onInvalid(d.raw)
+		}
+		d.invalid = false
+		if err == nil && d.tee != nil {
+			if _, err := d.tee.Write(d.raw); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return b, err
+}
+
+// nextValue is like next, but trims trailing whitespace from the value.
+func (d *Decoder) nextValue() ([]byte, error) {
+	b, err := d.next()
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimRightFunc(b, wsRune), nil
+}
+
+// peek scans the next record if necessary, and returns its value bytes without
+// consuming it. Invalid records are consumed if skipped, but remain buffered
+// otherwise.
+func (d *Decoder) peek() ([]byte, error) {
+	for {
+		if !d.scanNext() {
+			if err := d.s.Err(); err != nil {
+				if err == bufio.ErrTooLong {
+					return nil, fmt.Errorf("record exceeds maximum size of %d bytes: %w", d.max, err)
+				}
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+		if err := d.r.err; err != nil {
+			// The scanner treats read errors like EOF, so the record may be truncated.
+			return nil, err
+		}
+		raw := d.s.Bytes()
+		if d.skipPreamble && d.tokOff == 0 && (len(raw) == 0 || raw[0] != rs) {
+			d.buffered = false
+			continue
+		}
+		if d.skipEmpty && len(raw) > 0 && raw[0] == rs && blank(raw[1:]) {
+			d.buffered = false
+			continue
+		}
+		if d.partial && d.tokEOF && !endsWithLF(raw) {
+			d.invalid = true
+			return nil, fmt.Errorf("%w: %q", ErrPartialRecord, string(raw))
+		}
+		b, err := d.check(raw)
+		d.invalid = err != nil
+		if err == nil {
+			return b, nil
+		}
+		if !d.skipInvalid {
+			return nil, err
+		}
+		if d.onInvalid != nil {
+			d.onInvalid(raw)
+		}
+		d.invalid = false
+		d.read++
+		d.skipped = append(d.skipped, append([]byte(nil), raw...))
+		d.buffered = false
+	}
+}
+
+// check returns the value bytes of the record raw, or an error if it is invalid.
+func (d *Decoder) check(raw []byte) ([]byte, error) {
+	b, ok := d.value(raw)
+	if d.strict {
+		rule := strictRule(raw, ok)
+		if rule == nil && multipleValues(b) {
+			rule = ErrMultipleValues
+		}
+		if rule != nil {
+			return nil, &StrictError{Rule: rule, Record: append([]byte(nil), raw...)}
+		}
+	}
 	if !ok {
-		return fmt.Errorf("invalid record: %q", string(b))
+		return nil, &InvalidRecordError{Record: append([]byte(nil), b...)}
+	}
+	if d.requireLF && !endsWithLF(raw) {
+		return nil, &StrictError{Rule: ErrNoLF, Record: append([]byte(nil), raw...)}
+	}
+	return b, nil
+}
+
+// DecodeAllFast splits the in-memory sequence b into records in a single pass,
+// checks each with RecordValue, and returns their value bytes, without trailing
+// whitespace. Records are split as with ScanRecord, but without the overhead of
+// a bufio.Scanner, and the returned values alias b rather than being copied.
+// The first invalid record is returned as an *InvalidRecordError, annotated
+// with its index, along with the values preceding it.
+func DecodeAllFast(b []byte) ([]json.RawMessage, error) {
+	vs := make([]json.RawMessage, 0, bytes.Count(b, rsBytes[:]))
+	for i := 0; len(b) > 0; i++ {
+		// Skip consecutive RS bytes, retaining the last.
+		j := 0
+		for j+1 < len(b) && b[j] == rs && b[j+1] == rs {
+			j++
+		}
+		b = b[j:]
+		end := len(b)
+		if k := bytes.IndexByte(b[1:], rs); k >= 0 {
+			end = k + 1
+		}
+		v, ok := RecordValue(b[:end])
+		if !ok {
+			return vs, fmt.Errorf("record %d: %w", i, &InvalidRecordError{Record: append([]byte(nil), v...)})
+		}
+		vs = append(vs, bytes.TrimRightFunc(v, wsRune))
+		b = b[end:]
 	}
-	return d.fn(b, v)
+	return vs, nil
 }
 
 // RecordValue returns the *value* bytes from a JSON text sequence record and a flag
 // indicating if the *record* is valid. This is *NOT* a validation of any contained JSON,
-// which could itself be invalid or contain extra trailing values.
+// which could itself be invalid or contain extra trailing values. Empty records,
+// holding only whitespace, are invalid.
 //
 // See section 2.4: Top-Level Values: numbers, true, false, and null.
 // https://tools.ietf.org/html/rfc7464#section-2.4
@@ -152,61 +972,116 @@ func RecordValue(b []byte) ([]byte, bool) {
 	}
 	// Drop rs and leading whitespace.
 	b = bytes.TrimLeftFunc(b[1:], wsRune)
+	return b, validValue(b, wsSet)
+}
+
+// RecordValueWith is like RecordValue, but a top-level number, true, false, or
+// null value may be terminated by any of the bytes in ws, rather than only by
+// whitespace. The set replaces the default of space, tab, LF, and CR, so
+// include them to extend it, e.g. with a form feed. Leading whitespace is
+// trimmed as usual.
+func RecordValueWith(b []byte, ws []byte) ([]byte, bool) {
+	if len(b) < 2 {
+		return b, false
+	}
+	if b[0] != rs {
+		return b, false
+	}
+	b = bytes.TrimLeftFunc(b[1:], wsRune)
+	return b, validValue(b, ws)
+}
+
+// RecordValueStrict is like RecordValue, but does not permit whitespace between
+// the RS and the value, so that records are only valid if tightly framed.
+func RecordValueStrict(b []byte) ([]byte, bool) {
+	if len(b) < 2 {
+		return b, false
+	}
+	if b[0] != rs {
+		return b, false
+	}
+	b = b[1:]
+	if wsByte(b[0]) {
+		return b, false
+	}
+	return b, validValue(b, wsSet)
+}
+
+// ValidRecord reports whether b is a valid JSON text sequence record, as
+// determined by RecordValue.
+func ValidRecord(b []byte) bool {
+	_, ok := RecordValue(b)
+	return ok
+}
+
+// ValidValue reports whether the value bytes b, following a record's RS, are
+// neither empty nor truncated. As with RecordValue, this is *NOT* a validation of any
+// contained JSON.
+func ValidValue(b []byte) bool {
+	return validValue(bytes.TrimLeftFunc(b, wsRune), wsSet)
+}
+
+// validValue is ValidValue for b without leading whitespace, where the bytes in
+// ws may terminate a value.
+func validValue(b []byte, ws []byte) bool {
 	if len(b) == 0 {
-		// Empty record.
-		return b, true
+		// Empty record, which holds no JSON text.
+		return false
+	}
+	// Terminators are single bytes, so a multi-byte UTF-8 sequence following
+	// a value never terminates it, even if it encodes a space character.
+	term := func(c byte) bool {
+		return bytes.IndexByte(ws, c) >= 0
 	}
 	// A number, true, false, or null value could be truncated if not
 	// followed by whitespace.
 	switch b[0] {
 	case 'n':
 		if bytes.HasPrefix(b, []byte("null")) {
-			if len(b) > 4 && wsByte(b[4]) {
-				return b, true
-			}
-			return b, false
+			return len(b) > 4 && term(b[4])
 		}
 	case 't':
 		if bytes.HasPrefix(b, []byte("true")) {
-			if len(b) > 4 && wsByte(b[4]) {
-				return b, true
-			}
-			return b, false
+			return len(b) > 4 && term(b[4])
 		}
 	case 'f':
 		if bytes.HasPrefix(b, []byte("false")) {
-			if len(b) > 5 && wsByte(b[5]) {
-				return b, true
-			}
-			return b, false
+			return len(b) > 5 && term(b[5])
 		}
 	case '-':
 		if len(b) > 1 && '0' <= b[1] && b[1] <= '9' {
-			t := bytes.TrimLeft(b[2:], digitSet)
-			if len(t) > 0 && wsByte(t[0]) {
-				return b, true
-			}
-			return b, false
+			t := bytes.TrimLeft(b[2:], numberSet)
+			return len(t) > 0 && term(t[0])
 		}
 	case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
-		t := bytes.TrimLeft(b[1:], digitSet)
-		if len(t) > 0 && wsByte(t[0]) {
-			return b, true
-		}
-		return b, false
+		t := bytes.TrimLeft(b[1:], numberSet)
+		return len(t) > 0 && term(t[0])
 	}
 
-	return b, true
+	return true
 }
 
 // ScanRecord is a bufio.SplitFunc which splits JSON text sequence records.
 // Scanned bytes must be validated with the RecordValue function.
 func ScanRecord(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	return scanRecord(rs, data, atEOF)
+}
+
+// MakeScanRecord returns a bufio.SplitFunc like ScanRecord, but which splits
+// records beginning with sep rather than an RS.
+func MakeScanRecord(sep byte) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		return scanRecord(sep, data, atEOF)
+	}
+}
+
+// scanRecord is ScanRecord for records starting with sep.
+func scanRecord(sep byte, data []byte, atEOF bool) (advance int, token []byte, err error) {
 	if atEOF && len(data) == 0 {
 		return 0, nil, nil
 	}
 	// Find record start.
-	switch i := bytes.IndexByte(data, rs); {
+	switch i := bytes.IndexByte(data, sep); {
 	case i < 0:
 		if atEOF {
 			// Partial record.
@@ -216,23 +1091,80 @@ func ScanRecord(data []byte, atEOF bool) (advance int, token []byte, err error)
 		return 0, nil, nil
 	case i > 0:
 		// Partial record.
-		return i, data[0 : i-1], nil
+		return i, data[:i], nil
 	}
 	// else i == 0
 
-	// Drop consecutive leading rs's
-	for len(data) > 1 && data[1] == rs {
-		data = data[1:]
+	// Drop consecutive leading rs's, but still advance past them.
+	skip := 0
+	for skip+1 < len(data) && data[skip+1] == sep {
+		skip++
 	}
+	data = data[skip:]
 
 	// Find end or next record.
-	i := bytes.IndexByte(data[1:], rs)
+	i := bytes.IndexByte(data[1:], sep)
 	if i < 0 {
 		if atEOF {
+			return skip + len(data), data, nil
+		}
+		// Request more data.
+		return 0, nil, nil
+	}
+	return skip + 1 + i, data[:1+i], nil
+}
+
+// ScanRecordLF is a bufio.SplitFunc like ScanRecord, except that a record also
+// ends at the first LF following the start of its value. Records are returned
+// as soon as they are complete, rather than when the next record begins, which
+// reduces latency when reading from a slow stream. In exchange, values must not
+// contain LFs, so indented values are not supported.
+func ScanRecordLF(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	// Find record start.
+	switch i := bytes.IndexByte(data, rs); {
+	case i < 0:
+		if atEOF {
+			// Partial record.
 			return len(data), data, nil
 		}
 		// Request more data.
 		return 0, nil, nil
+	case i > 0:
+		// Partial record.
+		return i, data[:i], nil
 	}
-	return 1 + i, data[:1+i], nil
+	// else i == 0
+
+	// Drop consecutive leading rs's, but still advance past them.
+	skip := 0
+	for skip+1 < len(data) && data[skip+1] == rs {
+		skip++
+	}
+	data = data[skip:]
+
+	// Find end of value or next record, after any leading whitespace.
+	v := 1 + len(data[1:]) - len(bytes.TrimLeftFunc(data[1:], wsRune))
+	i := bytes.IndexAny(data[v:], "\x1e\n")
+	if i < 0 {
+		if atEOF {
+			return skip + len(data), data, nil
+		}
+		// Request more data.
+		return 0, nil, nil
+	}
+	end := v + i
+	if data[end] == lf {
+		end++
+	}
+	return skip + end, data[:end], nil
+}
+
+// NewDecoderLF is like NewDecoder, but splits records with ScanRecordLF.
+func NewDecoderLF(r io.Reader) *Decoder {
+	d := NewDecoder(r)
+	d.split = ScanRecordLF
+	return d
 }
@@ -0,0 +1,1592 @@
+package jsonseq
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// chunkReader returns data from s in chunks of at most n bytes.
+type chunkReader struct {
+	s string
+	n int
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	if len(c.s) == 0 {
+		return 0, io.EOF
+	}
+	if len(p) > c.n {
+		p = p[:c.n]
+	}
+	n := copy(p, c.s)
+	c.s = c.s[n:]
+	return n, nil
+}
+
+func scanAll(t *testing.T, r io.Reader) []string {
+	t.Helper()
+	s := bufio.NewScanner(r)
+	s.Split(ScanRecord)
+	var got []string
+	for s.Scan() {
+		got = append(got, s.Text())
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestScanRecord_chunkBoundaries(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{"single", "\x1e{}\n", []string{"\x1e{}\n"}},
+		{"multiple", "\x1e1\n\x1e\"a\"\n\x1e[true]\n", []string{"\x1e1\n", "\x1e\"a\"\n", "\x1e[true]\n"}},
+		{"consecutive-rs", "\x1e\x1e\x1e{}\n\x1e\x1enull\n", []string{"\x1e{}\n", "\x1enull\n"}},
+		{"leading-junk", "junk\n\x1e{}\n", []string{"junk\n", "\x1e{}\n"}},
+		{"no-trailing-lf", "\x1e{}\n\x1e{}", []string{"\x1e{}\n", "\x1e{}"}},
+		{"trailing-rs", "\x1e{}\n\x1e", []string{"\x1e{}\n", "\x1e"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			for n := 1; n <= len(tt.in); n++ {
+				got := scanAll(t, &chunkReader{s: tt.in, n: n})
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("chunk size %d: got %q but want %q", n, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecoder_chunkBoundaries(t *testing.T) {
+	const in = "\x1e{\"id\":1}\n\x1e\x1e1234 \n\x1etrue\n\x1e\"s\"\n"
+	want := []interface{}{map[string]interface{}{"id": 1.0}, 1234.0, true, "s"}
+	for n := 1; n <= len(in); n++ {
+		d := NewDecoder(&chunkReader{s: in, n: n})
+		var got []interface{}
+		for {
+			var v interface{}
+			if err := d.Decode(&v); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("chunk size %d: %v", n, err)
+			}
+			got = append(got, v)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("chunk size %d: got %v but want %v", n, got, want)
+		}
+	}
+}
+
+func TestDecoder_bufferBoundary(t *testing.T) {
+	// Place the RS and LF of the second record at either side of the
+	// scanner's initial 4096 byte buffer.
+	for pad := 4080; pad < 4100; pad++ {
+		in := "\x1e\"" + strings.Repeat("a", pad) + "\"\n\x1e2 \n"
+		d := NewDecoder(strings.NewReader(in))
+		var s string
+		if err := d.Decode(&s); err != nil {
+			t.Fatalf("pad %d: %v", pad, err)
+		}
+		if len(s) != pad {
+			t.Errorf("pad %d: got string of length %d", pad, len(s))
+		}
+		var i int
+		if err := d.Decode(&i); err != nil {
+			t.Fatalf("pad %d: %v", pad, err)
+		}
+		if i != 2 {
+			t.Errorf("pad %d: got %d but want 2", pad, i)
+		}
+		if err := d.Decode(&i); err != io.EOF {
+			t.Errorf("pad %d: expected io.EOF but got %v", pad, err)
+		}
+	}
+}
+
+func TestValidateStream(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		in      string
+		max     int
+		want    int
+		wantErr bool
+	}{
+		{"empty", "", 0, 0, false},
+		{"valid", "\x1e{}\n\x1e[1,2]\n\x1etrue\n", 0, 3, false},
+		{"at-limit", "\x1e{}\n\x1e[1,2]\n\x1etrue\n", 3, 3, false},
+		{"over-limit", "\x1e{}\n\x1e[1,2]\n\x1etrue\n", 2, 2, true},
+		{"invalid-record", "\x1e{}\n\x1e1234", 0, 1, true},
+		{"invalid-json", "\x1e{}\n\x1e{\"a\"}\n", 0, 1, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidateStream(strings.NewReader(tt.in), tt.max)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %d records but want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecoder_DecodeContext(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	d := NewDecoder(pr)
+
+	go func() { _, _ = pw.Write([]byte("\x1e1 \n\x1e2 \n")) }()
+	var v int
+	if err := d.DecodeContext(context.Background(), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	// The second record is incomplete until the next RS or EOF, which never come.
+	if err := d.DecodeContext(ctx, &v); err != context.DeadlineExceeded {
+		t.Errorf("expected %v but got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestDecoder_SetMaxRecordSize(t *testing.T) {
+	in := "\x1e\"" + strings.Repeat("a", 100000) + "\"\n"
+
+	var s string
+	if err := NewDecoder(strings.NewReader(in)).Decode(&s); !errors.Is(err, bufio.ErrTooLong) {
+		t.Errorf("expected %v but got %v", bufio.ErrTooLong, err)
+	}
+
+	d := NewDecoder(strings.NewReader(in))
+	d.SetMaxRecordSize(len(in))
+	if err := d.Decode(&s); err != nil {
+		t.Fatal(err)
+	}
+	if len(s) != 100000 {
+		t.Errorf("got string of length %d", len(s))
+	}
+
+	// Limits smaller than the initial buffer.
+	small := "\x1e\"" + strings.Repeat("a", 1000) + "\"\n"
+	d = NewDecoder(strings.NewReader(small))
+	d.SetMaxRecordSize(100)
+	if err := d.Decode(&s); !errors.Is(err, bufio.ErrTooLong) {
+		t.Errorf("expected %v but got %v", bufio.ErrTooLong, err)
+	}
+	d = NewDecoder(strings.NewReader(small + small))
+	d.SetMaxRecordSize(len(small))
+	for i := 0; i < 2; i++ {
+		if err := d.Decode(&s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d.Reset(strings.NewReader(small))
+	d.SetMaxRecordSize(len(small) - 1)
+	if err := d.Decode(&s); !errors.Is(err, bufio.ErrTooLong) {
+		t.Errorf("expected %v after Reset but got %v", bufio.ErrTooLong, err)
+	}
+}
+
+func TestDecoder_InvalidRecordError(t *testing.T) {
+	d := NewDecoder(strings.NewReader("\x1e1234\x1etrue\n"))
+	var v interface{}
+	err := d.Decode(&v)
+	var ire *InvalidRecordError
+	if !errors.As(err, &ire) {
+		t.Fatalf("expected *InvalidRecordError but got %T: %v", err, err)
+	}
+	if string(ire.Record) != "1234" {
+		t.Errorf("got record %q but want %q", ire.Record, "1234")
+	}
+	if err.Error() != `invalid record: "1234"` {
+		t.Errorf("unexpected message: %s", err)
+	}
+}
+
+// limitWriter fails once n bytes have been written.
+type limitWriter struct {
+	n int
+}
+
+var errLimit = errors.New("limit reached")
+
+func (w *limitWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		n := w.n
+		w.n = 0
+		return n, errLimit
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestWriteRecords(t *testing.T) {
+	records := [][]byte{[]byte(`{"id":1}`), []byte(`true`), []byte(`"s"`)}
+	const want = "\x1e{\"id\":1}\n\x1etrue\n\x1e\"s\"\n"
+
+	var b bytes.Buffer
+	if err := WriteRecords(&b, records...); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != want {
+		t.Errorf("got %q but want %q", b.String(), want)
+	}
+
+	for _, tt := range []struct {
+		limit int
+		index string
+	}{
+		{0, "record 0"},
+		{10, "record 1"},
+		{11, "record 1"},
+		{15, "record 1"},
+		{16, "record 2"},
+	} {
+		err := WriteRecords(&limitWriter{n: tt.limit}, records...)
+		if !errors.Is(err, errLimit) || !strings.HasPrefix(err.Error(), tt.index) {
+			t.Errorf("limit %d: expected %s error but got %v", tt.limit, tt.index, err)
+		}
+	}
+}
+
+func TestWriteRecordValid(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteRecordValid(&b, []byte(`{"id":1}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteRecordValid(&b, []byte(`{"id":`)); !errors.Is(err, ErrInvalidJSON) {
+		t.Errorf("expected %v but got %v", ErrInvalidJSON, err)
+	}
+	if got, want := b.String(), "\x1e{\"id\":1}\n"; got != want {
+		t.Errorf("got %q but want %q", got, want)
+	}
+}
+
+func TestDecoder_Count(t *testing.T) {
+	const in = "\x1e{\"id\":1}\n\x1e1234\x1etrue\n"
+	d := NewDecoder(strings.NewReader(in))
+	for _, want := range []struct {
+		count int
+		bytes int64
+	}{{1, 10}, {1, 15}, {2, 21}} {
+		var v interface{}
+		_ = d.Decode(&v)
+		if got := d.Count(); got != want.count {
+			t.Errorf("got count %d but want %d", got, want.count)
+		}
+		if got := d.BytesRead(); got != want.bytes {
+			t.Errorf("got %d bytes read but want %d", got, want.bytes)
+		}
+	}
+}
+
+func TestRecordReader(t *testing.T) {
+	const in = "junk\x1e{\"id\":1}\n\x1e\x1e\x1etrue\n\x1e\x1e[1,\n2]"
+	want := []string{"{\"id\":1}\n", "true\n", "[1,\n2]"}
+	for n := 1; n <= len(in); n++ {
+		rr := NewRecordReader(&chunkReader{s: in, n: n})
+		var got []string
+		for {
+			r, err := rr.Next()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			b, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, string(b))
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("chunk size %d: got %q but want %q", n, got, want)
+		}
+	}
+}
+
+func TestValidRecord(t *testing.T) {
+	for _, tt := range []struct {
+		record string
+		want   bool
+	}{
+		{"\x1e{}\n", true},
+		{"\x1e \t{}", true},
+		{"\x1etrue\n", true},
+		{"\x1etrue", false},
+		{"\x1e-12 ", true},
+		{"\x1e-12", false},
+		{"{}\n", false},
+		{"\x1e", false},
+		{"\x1e \n", false},
+	} {
+		if got := ValidRecord([]byte(tt.record)); got != tt.want {
+			t.Errorf("ValidRecord(%q): got %t but want %t", tt.record, got, tt.want)
+		}
+		if got := ValidValue([]byte(tt.record)[1:]); len(tt.record) > 1 && tt.record[0] == rs && got != tt.want {
+			t.Errorf("ValidValue(%q): got %t but want %t", tt.record[1:], got, tt.want)
+		}
+	}
+}
+
+func TestDecoder_Reset(t *testing.T) {
+	d := NewDecoder(strings.NewReader("\x1e1 \n\x1e2 \n"))
+	d.SetSkipInvalid(true)
+	var i int
+	if err := d.Decode(&i); err != nil {
+		t.Fatal(err)
+	}
+
+	d.Reset(strings.NewReader("\x1e3\x1e4 \n"))
+	if err := d.Decode(&i); err != nil {
+		t.Fatal(err)
+	}
+	if i != 4 {
+		t.Errorf("got %d but want 4", i)
+	}
+	if d.Count() != 1 {
+		t.Errorf("got count %d but want 1", d.Count())
+	}
+	if len(d.Skipped()) != 1 {
+		t.Errorf("expected one skipped record but got %q", d.Skipped())
+	}
+	if err := d.Decode(&i); err != io.EOF {
+		t.Errorf("expected io.EOF but got %v", err)
+	}
+}
+
+func TestDecoder_DecodeRaw(t *testing.T) {
+	d := NewDecoder(strings.NewReader("\x1e{\"b\":1, \"a\":2.50}\n\x1e1234\x1e[1]\n"))
+	want := []string{`{"b":1, "a":2.50}`, "", "[1]"}
+	for _, w := range want {
+		raw, err := d.DecodeRaw()
+		if w == "" {
+			var ire *InvalidRecordError
+			if !errors.As(err, &ire) {
+				t.Errorf("expected *InvalidRecordError but got %v", err)
+			}
+			continue
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if string(raw) != w {
+			t.Errorf("got %q but want %q", raw, w)
+		}
+	}
+	if _, err := d.DecodeRaw(); err != io.EOF {
+		t.Errorf("expected io.EOF but got %v", err)
+	}
+}
+
+func TestDecoder_SetStrictSingleValue(t *testing.T) {
+	const in = "\x1e{\"id\":1} \n\x1e\"1234\"1234 \n\x1e[1] true\n"
+	d := NewDecoder(strings.NewReader(in))
+	d.SetStrictSingleValue(true)
+	for _, extra := range []string{"", "1234 \n", "true\n"} {
+		var v interface{}
+		err := d.Decode(&v)
+		if extra == "" {
+			if err != nil {
+				t.Error(err)
+			}
+			continue
+		}
+		var tde *TrailingDataError
+		if !errors.As(err, &tde) {
+			t.Errorf("expected *TrailingDataError but got %v", err)
+		} else if string(tde.Data) != extra {
+			t.Errorf("got trailing data %q but want %q", tde.Data, extra)
+		}
+	}
+}
+
+func TestSeparators(t *testing.T) {
+	for _, sep := range []Separators{
+		DefaultSeparators,
+		{Start: rs},
+		{Start: 0x02, End: 0x03},
+	} {
+		var b bytes.Buffer
+		e := NewEncoderT(&b)
+		if err := e.SetSeparators(sep); err != nil {
+			t.Fatal(err)
+		}
+		values := []interface{}{map[string]interface{}{"id": 1.0}, 1234.0, true, "s"}
+		for _, v := range values {
+			if err := e.Encode(v); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		d := NewDecoder(&b)
+		if err := d.SetSeparators(sep); err != nil {
+			t.Fatal(err)
+		}
+		var got []interface{}
+		for {
+			raw, err := d.Peek()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%+v: %v", sep, err)
+			}
+			if !json.Valid(raw) {
+				t.Errorf("%+v: invalid raw value %q", sep, raw)
+			}
+			var v interface{}
+			if err := d.Decode(&v); err != nil {
+				t.Fatalf("%+v: %v", sep, err)
+			}
+			got = append(got, v)
+		}
+		if !reflect.DeepEqual(got, values) {
+			t.Errorf("%+v: got %v but want %v", sep, got, values)
+		}
+	}
+
+	if err := NewDecoder(nil).SetSeparators(Separators{End: lf}); err == nil {
+		t.Error("expected error for missing start separator")
+	}
+}
+
+func TestDecoder_SetStrict(t *testing.T) {
+	const in = "junk\n\x1e{\"id\":1}\n\x1e1234\x1e[1]\x1e\"ok\"\n\x1e\"1234\"1234\n\x1e{}[]\n\x1etrue"
+	d := NewDecoder(strings.NewReader(in))
+	d.SetStrict(true)
+	for _, want := range []error{ErrNoRS, nil, ErrTruncated, ErrNoLF, nil, ErrMultipleValues, ErrMultipleValues, ErrTruncated} {
+		var v interface{}
+		err := d.Decode(&v)
+		if want == nil {
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			continue
+		}
+		var se *StrictError
+		if !errors.As(err, &se) || !errors.Is(err, want) {
+			t.Errorf("expected %v but got %v", want, err)
+		}
+	}
+	var v interface{}
+	if err := d.Decode(&v); err != io.EOF {
+		t.Errorf("expected io.EOF but got %v", err)
+	}
+}
+
+func TestWriteRecord_allocs(t *testing.T) {
+	record := []byte(`{"id":1}`)
+	rw := &RecordWriter{io.Discard}
+	if n := testing.AllocsPerRun(100, func() {
+		_ = WriteRecord(io.Discard, record)
+	}); n != 0 {
+		t.Errorf("WriteRecord: got %v allocs per record but want 0", n)
+	}
+	if n := testing.AllocsPerRun(100, func() {
+		_, _ = rw.Write(record)
+	}); n != 0 {
+		t.Errorf("RecordWriter.Write: got %v allocs per record but want 0", n)
+	}
+	s := strings.Repeat(`{"id":1}`, 8)
+	if n := testing.AllocsPerRun(100, func() {
+		_ = WriteRecordString(io.Discard, s)
+	}); n != 0 {
+		t.Errorf("WriteRecordString: got %v allocs per record but want 0", n)
+	}
+}
+
+func BenchmarkWriteRecord(b *testing.B) {
+	record := []byte(`{"id":1}`)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = WriteRecord(io.Discard, record)
+	}
+}
+
+func BenchmarkRecordWriter(b *testing.B) {
+	record := []byte(`{"id":1}` + "\n")
+	rw := &RecordWriter{io.Discard}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = rw.Write(record)
+	}
+}
+
+func TestScanRecordLF(t *testing.T) {
+	const in = "\x1e{\"id\":1}\n\x1e\x1e \n1234 \n\x1e[1]\x1etrue\n\x1e\"s\""
+	want := []string{"\x1e{\"id\":1}\n", "\x1e \n1234 \n", "\x1e[1]", "\x1etrue\n", "\x1e\"s\""}
+	for n := 1; n <= len(in); n++ {
+		s := bufio.NewScanner(&chunkReader{s: in, n: n})
+		s.Split(ScanRecordLF)
+		var got []string
+		for s.Scan() {
+			got = append(got, s.Text())
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("chunk size %d: got %q but want %q", n, got, want)
+		}
+	}
+}
+
+func TestNewDecoderLF(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go func() { _, _ = pw.Write([]byte("\x1e{\"id\":1}\n")) }()
+
+	// The record is returned without waiting for the next RS or EOF.
+	d := NewDecoderLF(pr)
+	var v struct{ ID int }
+	if err := d.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	if v.ID != 1 {
+		t.Errorf("got id %d but want 1", v.ID)
+	}
+}
+
+func TestDecoder_SetTee(t *testing.T) {
+	const in = "\x1e{\"id\":1}\n\x1e 1234 \n\x1etrue\x1e\"s\""
+	var tee bytes.Buffer
+	d := NewDecoder(strings.NewReader(in))
+	d.SetTee(&tee)
+	d.SetSkipInvalid(true)
+	for {
+		var v interface{}
+		if err := d.Decode(&v); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := tee.String(), "\x1e{\"id\":1}\n\x1e 1234 \n\x1e\"s\""; got != want {
+		t.Errorf("got %q but want %q", got, want)
+	}
+
+	d = NewDecoder(strings.NewReader(in))
+	d.SetTee(&limitWriter{})
+	var v interface{}
+	if err := d.Decode(&v); !errors.Is(err, errLimit) {
+		t.Errorf("expected %v but got %v", errLimit, err)
+	}
+}
+
+func TestDecoder_Raw(t *testing.T) {
+	const in = "\x1e{\"id\":1}\n\x1e 1234 \n\x1e\"s\""
+	d := NewDecoder(strings.NewReader(in))
+	for _, want := range []string{"\x1e{\"id\":1}\n", "\x1e 1234 \n", "\x1e\"s\""} {
+		var v interface{}
+		if err := d.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+		if got := string(d.Raw()); got != want {
+			t.Errorf("got %q but want %q", got, want)
+		}
+	}
+}
+
+func TestEncoder_SetAutoFlush(t *testing.T) {
+	var b bytes.Buffer
+	bw := bufio.NewWriter(&b)
+	e := NewEncoderT(bw)
+	if err := e.Encode(1); err != nil {
+		t.Fatal(err)
+	}
+	if b.Len() != 0 {
+		t.Errorf("got %q before flushing", b.String())
+	}
+	e.SetAutoFlush(true)
+	if err := e.Encode(2); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.String(), "\x1e1\n\x1e2\n"; got != want {
+		t.Errorf("got %q but want %q", got, want)
+	}
+}
+
+func TestWriteRecordN(t *testing.T) {
+	var b bytes.Buffer
+	n, err := WriteRecordN(&b, []byte(`{"id":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != b.Len() || n != 10 {
+		t.Errorf("got %d bytes but wrote %d", n, b.Len())
+	}
+	for _, limit := range []int{0, 1, 5, 9} {
+		n, err := WriteRecordN(&limitWriter{n: limit}, []byte(`{"id":1}`))
+		if !errors.Is(err, errLimit) || n != limit {
+			t.Errorf("limit %d: got %d bytes and error %v", limit, n, err)
+		}
+	}
+}
+
+func TestDecoder_SetErrOnPartial(t *testing.T) {
+	for _, tt := range []struct {
+		in      string
+		partial bool
+	}{
+		{"\x1e{\"id\":1}\n\x1e{\"id\":2}\n", false},
+		{"\x1e{\"id\":1}\x1e{\"id\":2}\n", false},
+		{"\x1e{\"id\":1}\n\x1e{\"id\":2}", true},
+		{"\x1e{\"id\":1}\n\x1e{\"id\"", true},
+	} {
+		d := NewDecoder(strings.NewReader(tt.in))
+		d.SetErrOnPartial(true)
+		var err error
+		for err == nil {
+			var v interface{}
+			err = d.Decode(&v)
+		}
+		if got := errors.Is(err, ErrPartialRecord); got != tt.partial {
+			t.Errorf("%q: expected partial %t but got %v", tt.in, tt.partial, err)
+		} else if !tt.partial && err != io.EOF {
+			t.Errorf("%q: unexpected error: %v", tt.in, err)
+		}
+	}
+}
+
+func TestNewMultiDecoder(t *testing.T) {
+	d := NewMultiDecoder(
+		strings.NewReader("\x1e{\"id\":1}\n"),
+		strings.NewReader("{\"id\":2}\n \n"),
+		strings.NewReader(" \n"),
+		strings.NewReader("\x1e{\"id\""),
+		strings.NewReader(":3}\n"),
+		strings.NewReader(""),
+		strings.NewReader("{\"id\":4}"),
+	)
+	var got []int
+	for {
+		var v struct{ ID int }
+		if err := d.Decode(&v); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v.ID)
+	}
+	if want := []int{1, 2, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v but want %v", got, want)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	const in = "junk\x1e{\"id\":1}\n\x1e\x1e 1234 \n\x1e\"s\""
+	r := strings.NewReader(in)
+	if _, err := r.Seek(4, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	ix, err := BuildIndex(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{`{"id":1}`, `1234`, `"s"`}
+	if ix.Len() != len(want) {
+		t.Fatalf("got %d records but want %d", ix.Len(), len(want))
+	}
+	for _, n := range []int{2, 0, 1} {
+		got, err := ix.Record(r, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want[n] {
+			t.Errorf("record %d: got %q but want %q", n, got, want[n])
+		}
+	}
+	if _, err := ix.Record(r, 3); err == nil {
+		t.Error("expected out of range error")
+	}
+}
+
+func TestRecordValueStrict(t *testing.T) {
+	for _, tt := range []struct {
+		record string
+		want   bool
+	}{
+		{"\x1e{}\n", true},
+		{"\x1e \t{}", false},
+		{"\x1e\n", false},
+		{"\x1etrue\n", true},
+		{"\x1etrue", false},
+		{"{}\n", false},
+		{"\x1e", false},
+	} {
+		if _, got := RecordValueStrict([]byte(tt.record)); got != tt.want {
+			t.Errorf("RecordValueStrict(%q): got %t but want %t", tt.record, got, tt.want)
+		}
+	}
+}
+
+func TestWriteRecordSafe(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteRecordSafe(&b, []byte(`"a\u001eb"`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteRecordSafe(&b, []byte("\"a\x1eb\"")); !errors.Is(err, ErrEmbeddedRS) {
+		t.Errorf("expected %v but got %v", ErrEmbeddedRS, err)
+	}
+	if got, want := b.String(), "\x1e\"a\\u001eb\"\n"; got != want {
+		t.Errorf("got %q but want %q", got, want)
+	}
+}
+
+func TestBufferedWriter(t *testing.T) {
+	var out bytes.Buffer
+	b := NewBufferedWriter(&out, 16)
+	if err := b.WriteRecord([]byte(`{"id":1}`)); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 0 || b.Buffered() != 10 {
+		t.Errorf("got %d written and %d buffered", out.Len(), b.Buffered())
+	}
+	if err := b.WriteRecord([]byte(`{"id":2}`)); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 20 || b.Buffered() != 0 {
+		t.Errorf("got %d written and %d buffered", out.Len(), b.Buffered())
+	}
+	if err := b.WriteRecord([]byte(`true`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "\x1e{\"id\":1}\n\x1e{\"id\":2}\n\x1etrue\n"; got != want {
+		t.Errorf("got %q but want %q", got, want)
+	}
+
+	b.Reset(&limitWriter{n: 4})
+	_ = b.WriteRecord([]byte(`true`))
+	if err := b.Flush(); !errors.Is(err, errLimit) || b.Buffered() != 2 {
+		t.Errorf("got error %v with %d buffered", err, b.Buffered())
+	}
+}
+
+func TestDecoder_SetRequireLF(t *testing.T) {
+	d := NewDecoder(strings.NewReader("\x1e{\"id\":1}\n\x1e{\"id\":2}\x1e 3 \r\n"))
+	d.SetRequireLF(true)
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	var se *StrictError
+	if err := d.Decode(&v); !errors.As(err, &se) || se.Rule != ErrNoLF {
+		t.Errorf("expected %v but got %v", ErrNoLF, err)
+	}
+	if err := d.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEncoder_SetPreamble(t *testing.T) {
+	var b bytes.Buffer
+	e := NewEncoderT(&b)
+	e.SetPreamble(2)
+	for i := 1; i <= 2; i++ {
+		if err := e.Encode(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := b.String(), "\x1e\x1e\x1e1\n\x1e2\n"; got != want {
+		t.Errorf("got %q but want %q", got, want)
+	}
+	var got []int
+	d := NewDecoder(&b)
+	for {
+		var v int
+		if err := d.Decode(&v); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v)
+	}
+	if want := []int{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v but want %v", got, want)
+	}
+}
+
+func TestDecoder_SetSkipPreamble(t *testing.T) {
+	const in = "\xef\xbb\xbf\x1e{\"id\":1}\n\x1ejunk\n"
+	d := NewDecoder(strings.NewReader(in))
+	d.SetSkipPreamble(true)
+	var v struct{ ID int }
+	if err := d.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	if v.ID != 1 {
+		t.Errorf("got id %d but want 1", v.ID)
+	}
+	if got := d.InputOffset(); got != 3 {
+		t.Errorf("got offset %d but want 3", got)
+	}
+	// Later invalid records are unaffected.
+	if err := d.Decode(&v); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestDecoder_Resync(t *testing.T) {
+	d := NewDecoder(strings.NewReader("\x1e1234\x1e{\"id\":1}\n"))
+	var ire *InvalidRecordError
+	if _, err := d.Peek(); !errors.As(err, &ire) {
+		t.Fatalf("expected invalid record error but got %v", err)
+	}
+	if err := d.Resync(); err != nil {
+		t.Fatal(err)
+	}
+	var v struct{ ID int }
+	if err := d.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	if v.ID != 1 {
+		t.Errorf("got id %d but want 1", v.ID)
+	}
+	if err := d.Resync(); err != io.EOF {
+		t.Errorf("expected EOF but got %v", err)
+	}
+
+	// Discarded records are numbered, and passed to OnInvalid.
+	d = NewDecoder(strings.NewReader("\x1e1\n\x1e1234\x1e3\n\x1e4\n"))
+	var invalid []string
+	d.OnInvalid(func(raw []byte) { invalid = append(invalid, string(raw)) })
+	var i int
+	if n, err := d.DecodeNumbered(&i); err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Errorf("got record %d but want 1", n)
+	}
+	if _, err := d.Peek(); !errors.As(err, &ire) {
+		t.Fatalf("expected invalid record error but got %v", err)
+	}
+	if err := d.Resync(); err != nil {
+		t.Fatal(err)
+	}
+	for want := 3; want <= 4; want++ {
+		if n, err := d.DecodeNumbered(&i); err != nil {
+			t.Fatal(err)
+		} else if n != want || i != want {
+			t.Errorf("got record %d with %d but want %d", n, i, want)
+		}
+	}
+	if want := []string{"\x1e1234"}; !reflect.DeepEqual(invalid, want) {
+		t.Errorf("got invalid records %q but want %q", invalid, want)
+	}
+}
+
+func TestRecordValue_topLevel(t *testing.T) {
+	for _, tt := range []struct {
+		value string
+		bare  bool // Requires trailing whitespace.
+	}{
+		{`null`, true},
+		{`true`, true},
+		{`false`, true},
+		{`0`, true},
+		{`-12`, true},
+		{`12.34`, true},
+		{`-0.5e+10`, true},
+		{`1E-3`, true},
+		{`"s"`, false},
+		{`{"id":1}`, false},
+		{`[1,2]`, false},
+	} {
+		for _, c := range []struct {
+			suffix string
+			want   bool
+		}{
+			{"\n", true},
+			{" ", true},
+			{"", !tt.bare},
+			{"\u00a0", !tt.bare},
+			{"\xff", !tt.bare},
+		} {
+			record := "\x1e" + tt.value + c.suffix
+			if got := ValidRecord([]byte(record)); got != c.want {
+				t.Errorf("ValidRecord(%q): got %t but want %t", record, got, c.want)
+			}
+		}
+
+		// Decode the value from every possible chunk boundary.
+		in := "\x1e" + tt.value + "\n\x1e" + tt.value + "\n"
+		for n := 1; n <= len(in); n++ {
+			d := NewDecoder(&chunkReader{s: in, n: n})
+			for i := 0; i < 2; i++ {
+				raw, err := d.DecodeRaw()
+				if err != nil {
+					t.Fatalf("%q: chunk size %d: %v", tt.value, n, err)
+				}
+				if string(raw) != tt.value {
+					t.Errorf("%q: chunk size %d: got %q", tt.value, n, raw)
+				}
+			}
+		}
+	}
+}
+
+func TestWriter_ReadFrom(t *testing.T) {
+	var b bytes.Buffer
+	// Hide strings.Reader's WriteTo, which io.Copy would prefer.
+	src := struct{ io.Reader }{strings.NewReader("\x1e{\"id\":1}\x1e\x1e 1234 \r\n\x1e\"s\"")}
+	n, err := io.Copy(NewWriter(&b), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = "\x1e{\"id\":1}\n\x1e1234\n\x1e\"s\"\n"
+	if b.String() != want || n != int64(len(want)) {
+		t.Errorf("got %q (%d bytes) but want %q", b.String(), n, want)
+	}
+
+	// Sources implementing io.WriterTo write to it in arbitrary chunks, as when
+	// ReadFrom is hidden.
+	for n := 1; n <= 8; n++ {
+		b.Reset()
+		w := NewWriter(&b)
+		if _, err := io.Copy(struct{ io.Writer }{w}, &chunkReader{s: "\x1e 1 \n\x1e{\"a\":1}\n", n: n}); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if want := "\x1e1\n\x1e{\"a\":1}\n"; b.String() != want {
+			t.Errorf("chunk size %d: got %q but want %q", n, b.String(), want)
+		}
+	}
+	b.Reset()
+	w := NewWriter(&b)
+	if _, err := io.Copy(w, strings.NewReader("\x1e 1 \n\x1e{\"a\":1}\n")); err != nil {
+		t.Fatal(err)
+	} else if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "\x1e1\n\x1e{\"a\":1}\n"; b.String() != want {
+		t.Errorf("got %q but want %q", b.String(), want)
+	}
+	_, err = NewWriter(&b).Write([]byte("\x1e{\"id\":1}\n\x1e1234\x1e"))
+	var ire *InvalidRecordError
+	if !errors.As(err, &ire) || !strings.HasPrefix(err.Error(), "record 1") {
+		t.Errorf("expected record 1 invalid but got %v", err)
+	}
+
+	b.Reset()
+	_, err = NewWriter(&b).ReadFrom(strings.NewReader("\x1e{\"id\":1}\n\x1e1234"))
+	if !errors.As(err, &ire) || !strings.HasPrefix(err.Error(), "record 1") {
+		t.Errorf("expected record 1 invalid but got %v", err)
+	}
+}
+
+func TestEncoder_indentRoundTrip(t *testing.T) {
+	values := []interface{}{
+		map[string]interface{}{"id": 1.0, "tags": []interface{}{"a", "b"}},
+		[]interface{}{},
+		true,
+		12.5,
+	}
+	var std, seq bytes.Buffer
+	enc := NewEncoder(&std)
+	enc.SetIndent("\t", "  ")
+	e := NewEncoderT(&seq)
+	e.SetIndent("\t", "  ")
+	for _, v := range values {
+		if err := enc.Encode(v); err != nil {
+			t.Fatal(err)
+		}
+		if err := e.Encode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if std.String() != seq.String() {
+		t.Errorf("encoders differ: %q and %q", std.String(), seq.String())
+	}
+
+	d := NewDecoder(&std)
+	d.SetStrict(true)
+	for i := 0; ; i++ {
+		var v interface{}
+		if err := d.Decode(&v); err == io.EOF {
+			if i != len(values) {
+				t.Errorf("got %d records but want %d", i, len(values))
+			}
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(v, values[i]) {
+			t.Errorf("record %d: got %v but want %v", i, v, values[i])
+		}
+		if raw := d.Raw(); bytes.HasSuffix(raw, []byte("\n\n")) || raw[len(raw)-1] != '\n' {
+			t.Errorf("record %d: expected a single trailing LF: %q", i, raw)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+		want int
+	}{
+		{"\x1e{\"a\":1,\"b\":2}\n\x1e[1]\n", "\x1e{ \"b\": 2.0, \"a\": 1 }\n\x1e [ 1 ]\n", -1},
+		{"\x1e1 \n\x1e2 \n", "\x1e1 \n\x1e3 \n", 1},
+		{"\x1e1 \n", "\x1e1 \n\x1e2 \n", 1},
+		{"", "", -1},
+	} {
+		got, err := Diff([]byte(tt.a), []byte(tt.b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Diff(%q, %q): got %d but want %d", tt.a, tt.b, got, tt.want)
+		}
+		if eq, _ := Equal([]byte(tt.a), []byte(tt.b)); eq != (tt.want < 0) {
+			t.Errorf("Equal(%q, %q): got %t", tt.a, tt.b, eq)
+		}
+	}
+	if _, err := Diff([]byte("\x1e{\n"), nil); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestDecoder_SetLimit(t *testing.T) {
+	r := strings.NewReader(seqOfInts(10000))
+	d := NewDecoder(r)
+	d.SetLimit(2)
+	for i := 0; i < 2; i++ {
+		var v int
+		if err := d.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var v int
+	if err := d.Decode(&v); err != io.EOF {
+		t.Errorf("expected EOF but got %v", err)
+	}
+	if d.Count() != 2 {
+		t.Errorf("got count %d but want 2", d.Count())
+	}
+	if r.Len() == 0 {
+		t.Error("expected the reader not to be drained")
+	}
+
+	// Records read raw are counted and limited too.
+	d = NewDecoder(strings.NewReader(seqOfInts(3)))
+	d.SetLimit(1)
+	var sizes []int
+	d.OnRecord(func(size int) { sizes = append(sizes, size) })
+	var got []string
+	for d.Next() {
+		got = append(got, string(d.RawValue()))
+	}
+	if err := d.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q but want %q", got, want)
+	}
+	if d.Count() != 1 {
+		t.Errorf("got count %d but want 1", d.Count())
+	}
+	if want := []int{3}; !reflect.DeepEqual(sizes, want) {
+		t.Errorf("got sizes %v but want %v", sizes, want)
+	}
+	if _, err := d.DecodeRaw(); err != io.EOF {
+		t.Errorf("expected EOF but got %v", err)
+	}
+}
+
+func TestDecoder_Decode_writer(t *testing.T) {
+	d := NewDecoder(strings.NewReader("\x1e\"aGVsbG8=\" \n\x1e{\"id\":1}\n"))
+	var b bytes.Buffer
+	if err := d.Decode(&b); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.String(), `"aGVsbG8="`; got != want {
+		t.Errorf("got %q but want %q", got, want)
+	}
+	if err := d.Decode(&limitWriter{}); !errors.Is(err, errLimit) {
+		t.Errorf("expected %v but got %v", errLimit, err)
+	}
+}
+
+func TestMakeScanRecord(t *testing.T) {
+	const in = "|{\"id\":1}\n||1234 |\"s\""
+	want := []string{"|{\"id\":1}\n", "|1234 ", "|\"s\""}
+	for n := 1; n <= len(in); n++ {
+		s := bufio.NewScanner(&chunkReader{s: in, n: n})
+		s.Split(MakeScanRecord('|'))
+		var got []string
+		for s.Scan() {
+			got = append(got, s.Text())
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("chunk size %d: got %q but want %q", n, got, want)
+		}
+	}
+}
+
+func TestDecoder_OnRecord(t *testing.T) {
+	const in = "\x1e{\"id\":1}\n\x1e1234\x1e[1]\n\x1etru\n\x1e\"s\""
+	for _, skip := range []bool{false, true} {
+		d := NewDecoder(strings.NewReader(in))
+		d.SetSkipInvalid(skip)
+		var sizes []int
+		var invalid []string
+		d.OnRecord(func(size int) { sizes = append(sizes, size) })
+		d.OnInvalid(func(raw []byte) { invalid = append(invalid, string(raw)) })
+		for {
+			var v interface{}
+			if _, err := d.Peek(); err == io.EOF {
+				break
+			}
+			if err := d.Decode(&v); err == io.EOF {
+				break
+			}
+		}
+		if want := []int{10, 5, 4}; !reflect.DeepEqual(sizes, want) {
+			t.Errorf("skip %t: got sizes %v but want %v", skip, sizes, want)
+		}
+		if want := []string{"\x1e1234"}; !reflect.DeepEqual(invalid, want) {
+			t.Errorf("skip %t: got invalid %q but want %q", skip, invalid, want)
+		}
+	}
+}
+
+func TestWriteRecordString(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteRecordString(&b, `{"id":1}`); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.String(), "\x1e{\"id\":1}\n"; got != want {
+		t.Errorf("got %q but want %q", got, want)
+	}
+}
+
+func TestRecordValueWith(t *testing.T) {
+	ws := []byte(" \t\n\r\f")
+	for _, tt := range []struct {
+		record string
+		want   bool
+	}{
+		{"\x1e1234\f", true},
+		{"\x1e true\f", true},
+		{"\x1e1234\n", true},
+		{"\x1e1234", false},
+		{"\x1e{}", true},
+	} {
+		if _, got := RecordValueWith([]byte(tt.record), ws); got != tt.want {
+			t.Errorf("RecordValueWith(%q): got %t but want %t", tt.record, got, tt.want)
+		}
+	}
+	if ValidRecord([]byte("\x1e1234\f")) {
+		t.Error("expected form feed to be invalid by default")
+	}
+}
+
+func TestPool(t *testing.T) {
+	for i := 0; i < 3; i++ {
+		d := GetDecoder(strings.NewReader("\x1e1234\x1e{\"id\":1}\n"))
+		var v struct{ ID int }
+		if err := d.Decode(&v); err == nil {
+			t.Error("expected invalid record error")
+		}
+		if err := d.Decode(&v); err != nil || v.ID != 1 {
+			t.Errorf("got %v and id %d", err, v.ID)
+		}
+		// Settings must not leak to the next user.
+		d.SetSkipInvalid(true)
+		d.OnRecord(func(int) {})
+		PutDecoder(d)
+		if d.onRecord != nil || d.skipInvalid || d.r.r != nil {
+			t.Error("expected decoder to be cleared")
+		}
+
+		var b bytes.Buffer
+		e := GetEncoder(&b)
+		if err := e.Encode(map[string]string{"a": "<"}); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := b.String(), "\x1e{\"a\":\"\\u003c\"}\n"; got != want {
+			t.Errorf("got %q but want %q", got, want)
+		}
+		e.SetIndent("", "  ")
+		e.SetEscapeHTML(false)
+		PutEncoder(e)
+		if e.w != nil {
+			t.Error("expected encoder to be cleared")
+		}
+	}
+}
+
+func TestLengthPrefixed(t *testing.T) {
+	var b bytes.Buffer
+	e := NewLengthPrefixedEncoder(&b)
+	values := []interface{}{map[string]interface{}{"id": 1.0}, "line\nbreak", 1234.0}
+	for _, v := range values {
+		if err := e.Encode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := b.String(), "\x00\x00\x00\x08{\"id\":1}\x00\x00\x00\x0d\"line\\nbreak\"\x00\x00\x00\x041234"; got != want {
+		t.Errorf("got %q but want %q", got, want)
+	}
+
+	in := b.String()
+	for n := 1; n <= len(in); n++ {
+		d := NewLengthPrefixedDecoder(&chunkReader{s: in, n: n})
+		var got []interface{}
+		for {
+			var v interface{}
+			if err := d.Decode(&v); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, v)
+		}
+		if !reflect.DeepEqual(got, values) {
+			t.Errorf("chunk size %d: got %v but want %v", n, got, values)
+		}
+	}
+
+	d := NewLengthPrefixedDecoder(strings.NewReader(in[:len(in)-1]))
+	for i := 0; i < 2; i++ {
+		var v interface{}
+		if err := d.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var v interface{}
+	if err := d.Decode(&v); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected %v but got %v", io.ErrUnexpectedEOF, err)
+	}
+
+	// Zero-length values are invalid, rather than ending the input.
+	d = NewLengthPrefixedDecoder(strings.NewReader("\x00\x00\x00\x00\x00\x00\x00\x011"))
+	var ire *InvalidRecordError
+	if err := d.Decode(&v); !errors.As(err, &ire) {
+		t.Errorf("expected *InvalidRecordError but got %v", err)
+	}
+	if err := d.Decode(&v); err != nil {
+		t.Fatal(err)
+	} else if v != 1.0 {
+		t.Errorf("expected 1 but got %v", v)
+	}
+}
+
+func TestDecoder_DecodeNumbered(t *testing.T) {
+	const in = "\x1e{\"id\":1}\n\x1e1234\x1e{\"id\":3}\n\x1e{\"id\":4}\n"
+	for _, tt := range []struct {
+		skip bool
+		want []int
+	}{
+		{false, []int{1, 2, 3, 4}},
+		{true, []int{1, 3, 4}},
+	} {
+		d := NewDecoder(strings.NewReader(in))
+		d.SetSkipInvalid(tt.skip)
+		var got []int
+		for {
+			var v interface{}
+			n, err := d.DecodeNumbered(&v)
+			if err == io.EOF {
+				if n != 0 {
+					t.Errorf("got %d at EOF", n)
+				}
+				break
+			}
+			got = append(got, n)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("skip %t: got %v but want %v", tt.skip, got, tt.want)
+		}
+	}
+}
+
+func TestScanRecordStrict(t *testing.T) {
+	s := bufio.NewScanner(strings.NewReader("\x1e{\"id\":1}\n\x1e\x1etrue\n"))
+	s.Split(ScanRecordStrict)
+	var got []string
+	for s.Scan() {
+		got = append(got, s.Text())
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"\x1e{\"id\":1}\n", "\x1etrue\n"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q but want %q", got, want)
+	}
+
+	s = bufio.NewScanner(strings.NewReader("\xef\xbb\xbf\x1e{\"id\":1}\n"))
+	s.Split(ScanRecordStrict)
+	if s.Scan() {
+		t.Errorf("unexpected record %q", s.Text())
+	}
+	if err := s.Err(); !errors.Is(err, ErrNoRS) {
+		t.Errorf("expected %v but got %v", ErrNoRS, err)
+	}
+}
+
+func TestIsJSONWhitespace(t *testing.T) {
+	for b := 0; b < 256; b++ {
+		want := b == ' ' || b == '\t' || b == '\n' || b == '\r'
+		if got := IsJSONWhitespace(byte(b)); got != want {
+			t.Errorf("%q: got %t but want %t", b, got, want)
+		}
+	}
+}
+
+func TestDecodeAllFast(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"\x1e{\"id\":1}\n\x1e\x1e 1234 \n\x1e[1]\x1e\"s\"",
+		"junk\x1e{\"id\":1}\n",
+		"\x1e{\"id\":1}\n\x1e1234\x1etrue\n",
+		"\x1e{\"id\":1}\n\x1e",
+	} {
+		var want []json.RawMessage
+		var wantErr error
+		d := NewDecoder(strings.NewReader(in))
+		for {
+			raw, err := d.DecodeRaw()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				wantErr = err
+				break
+			}
+			want = append(want, raw)
+		}
+		got, err := DecodeAllFast([]byte(in))
+		if (err != nil) != (wantErr != nil) {
+			t.Errorf("%q: got error %v but want %v", in, err, wantErr)
+		}
+		if len(got) != len(want) || (len(got) > 0 && !reflect.DeepEqual(got, want)) {
+			t.Errorf("%q: got %q but want %q", in, got, want)
+		}
+	}
+}
+
+func benchSeq() []byte {
+	var b []byte
+	for i := 0; i < 1000; i++ {
+		b = AppendRecord(b, []byte(`{"id":1234,"name":"record","tags":["a","b","c"]}`))
+	}
+	return b
+}
+
+func BenchmarkDecodeAllFast(b *testing.B) {
+	seq := benchSeq()
+	b.SetBytes(int64(len(seq)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := DecodeAllFast(seq); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecoder_DecodeRaw(b *testing.B) {
+	seq := benchSeq()
+	b.SetBytes(int64(len(seq)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		d := NewDecoder(bytes.NewReader(seq))
+		for {
+			if _, err := d.DecodeRaw(); err == io.EOF {
+				break
+			} else if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestDecoder_SetSkipEmpty(t *testing.T) {
+	const in = "\x1e{\"id\":1}\n\x1e\n\x1e \r\n\x1e{\"id\":2}\n\x1e"
+	d := NewDecoder(strings.NewReader(in))
+	var v struct{ ID int }
+	if err := d.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	var ire *InvalidRecordError
+	if err := d.Decode(&v); !errors.As(err, &ire) {
+		t.Errorf("expected empty record to be invalid but got %v", err)
+	}
+
+	d = NewDecoder(strings.NewReader(in))
+	d.SetSkipEmpty(true)
+	var got []int
+	for {
+		if err := d.Decode(&v); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v.ID)
+	}
+	if want := []int{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v but want %v", got, want)
+	}
+	if len(d.Skipped()) != 0 {
+		t.Errorf("unexpected skipped records: %q", d.Skipped())
+	}
+}
+
+func TestNewDecoderAt(t *testing.T) {
+	const in = "\x1e{\"id\":1}\n\x1e\x1e{\"id\":2}\n\x1e{\"id\":3}\n"
+	d := NewDecoder(strings.NewReader(in))
+	var v struct{ ID int }
+	var positions []int64
+	for {
+		if err := d.Decode(&v); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		positions = append(positions, d.Position())
+	}
+	if want := []int64{10, 21, 31}; !reflect.DeepEqual(positions, want) {
+		t.Fatalf("got positions %v but want %v", positions, want)
+	}
+
+	r := strings.NewReader(in)
+	d, err := NewDecoderAt(r, positions[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []int{2, 3} {
+		if err := d.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+		if v.ID != want {
+			t.Errorf("got id %d but want %d", v.ID, want)
+		}
+	}
+	if got := d.Position(); got != positions[2] {
+		t.Errorf("got position %d but want %d", got, positions[2])
+	}
+
+	if d, err := NewDecoderAt(r, positions[2]); err != nil {
+		t.Fatal(err)
+	} else if err := d.Decode(&v); err != io.EOF {
+		t.Errorf("expected EOF but got %v", err)
+	}
+	if _, err := NewDecoderAt(r, 1); err == nil {
+		t.Error("expected error for offset within a record")
+	}
+	if _, err := NewDecoderAt(r, 1000); err == nil {
+		t.Error("expected error for offset past the end")
+	}
+}
+
+func TestDecoder_Buffered(t *testing.T) {
+	const in = "\x1e{\"id\":1}\n\x1e{\"id\":2}\n\x1e{\"id\":3}\n"
+	for _, peek := range []bool{false, true} {
+		r := &chunkReader{s: in + "tail", n: 32}
+		d := NewDecoder(r)
+		var v struct{ ID int }
+		if err := d.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+		if peek {
+			if _, err := d.Peek(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		b, err := io.ReadAll(io.MultiReader(d.Buffered(), strings.NewReader(r.s)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := string(b), in[10:]+"tail"; got != want {
+			t.Errorf("peek %t: got %q but want %q", peek, got, want)
+		}
+	}
+}
+
+func TestWriteRecordCanonical(t *testing.T) {
+	var b bytes.Buffer
+	for _, s := range []string{`{"id":1}`, " \t{\"id\":2}\r\n\n", "1234 \n"} {
+		if err := WriteRecordCanonical(&b, []byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := b.String(), "\x1e{\"id\":1}\n\x1e{\"id\":2}\n\x1e1234\n"; got != want {
+		t.Errorf("got %q but want %q", got, want)
+	}
+}
+
+func TestDecodeToArray_multipleValues(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := DecodeToArray(&buf, strings.NewReader("\x1e{\"id\":1} 2\n\x1e3\n"))
+	var tde *TrailingDataError
+	if !errors.As(err, &tde) {
+		t.Errorf("expected *TrailingDataError but got %v", err)
+	} else if !strings.HasPrefix(err.Error(), "record 0: ") {
+		t.Errorf("expected record index but got %q", err)
+	}
+	if n != 0 {
+		t.Errorf("expected 0 elements but got %d", n)
+	}
+}
+
+func TestDispatcher_Decode_invalid(t *testing.T) {
+	type A struct{ Type string }
+	var p Dispatcher
+	p.Register("a", func() interface{} { return new(A) })
+	d := NewDecoder(strings.NewReader("\x1e123\x1e{\"type\":\"a\"}\n"))
+	var ire *InvalidRecordError
+	if _, err := p.Decode(d); !errors.As(err, &ire) {
+		t.Fatalf("expected *InvalidRecordError but got %v", err)
+	}
+	v, err := p.Decode(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, ok := v.(*A); !ok || a.Type != "a" {
+		t.Errorf("got %#v but want type a", v)
+	}
+	if _, err := p.Decode(d); err != io.EOF {
+		t.Errorf("expected EOF but got %v", err)
+	}
+}
+
+func TestDecoder_More_skipped(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		in    string
+		setup func(*Decoder)
+		want  int // Records which More reports.
+	}{
+		{"invalid", "\x1e{}\n\x1e123", func(d *Decoder) { d.SetSkipInvalid(true) }, 1},
+		{"preamble", "\xef\xbb\xbf", func(d *Decoder) { d.SetSkipPreamble(true) }, 0},
+		{"empty", "\x1e{}\n\x1e\n", func(d *Decoder) { d.SetSkipEmpty(true) }, 1},
+	} {
+		d := NewDecoder(strings.NewReader(tt.in))
+		tt.setup(d)
+		var n int
+		for d.More() {
+			var v interface{}
+			if err := d.Decode(&v); err != nil {
+				t.Fatalf("%s: record %d: %v", tt.name, n, err)
+			}
+			n++
+		}
+		if n != tt.want {
+			t.Errorf("%s: got %d records but want %d", tt.name, n, tt.want)
+		}
+		if err := d.Err(); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package jsonseq
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// noLength is a placeholder for a length prefix.
+var noLength [4]byte
+
+// NewLengthPrefixedDecoder returns a Decoder which reads values each framed by
+// a 4-byte big-endian length prefix, rather than a JSON text sequence, and is
+// otherwise like NewDecoder. Length-prefixed framing is unambiguous, so values
+// are not checked by RecordValue, and options enforcing RFC 7464 framing, like
+// SetStrict, do not apply. Zero-length values are invalid records. The offsets
+// of records exclude their prefix.
+func NewLengthPrefixedDecoder(r io.Reader) *Decoder {
+	d := NewDecoder(r)
+	d.split = ScanLengthPrefixed
+	d.value = lengthPrefixedValue
+	return d
+}
+
+// lengthPrefixedValue returns b, which is valid unless empty.
+func lengthPrefixedValue(b []byte) ([]byte, bool) {
+	return b, len(b) > 0
+}
+
+// ScanLengthPrefixed is a bufio.SplitFunc which splits values each framed by a
+// 4-byte big-endian length prefix, without the prefix. A value cut short by the
+// end of the input is an io.ErrUnexpectedEOF error.
+func ScanLengthPrefixed(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if len(data) >= 4 {
+		n := binary.BigEndian.Uint32(data)
+		if uint64(len(data)-4) >= uint64(n) {
+			return 4 + int(n), data[4 : 4+n], nil
+		}
+	}
+	if atEOF && len(data) > 0 {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+	return 0, nil, nil
+}
+
+// NewLengthPrefixedEncoder returns an Encoder which writes values each framed
+// by a 4-byte big-endian length prefix, rather than as a JSON text sequence,
+// and is otherwise like NewEncoderT. Separators and preambles do not apply.
+func NewLengthPrefixedEncoder(w io.Writer) *Encoder {
+	e := NewEncoderT(w)
+	e.lengthPrefixed = true
+	return e
+}
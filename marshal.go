@@ -0,0 +1,33 @@
+package jsonseq
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Marshal returns a JSON text sequence holding a record for the JSON encoding
+// of each of values, per json.Marshal. Errors are annotated with the index of
+// the value.
+func Marshal(values []interface{}) ([]byte, error) {
+	var seq []byte
+	for i, v := range values {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", i, err)
+		}
+		seq = AppendRecord(seq, b)
+	}
+	return seq, nil
+}
+
+// Unmarshal parses the JSON text sequence data, and stores the value bytes of
+// each record in *out, as with DecodeAllFast, but copied from data. *out is
+// only modified on success.
+func Unmarshal(data []byte, out *[]json.RawMessage) error {
+	vs, err := DecodeAllFast(append([]byte(nil), data...))
+	if err != nil {
+		return err
+	}
+	*out = vs
+	return nil
+}
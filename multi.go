@@ -0,0 +1,76 @@
+package jsonseq
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// NewMultiDecoder returns a Decoder reading the concatenation of readers, in
+// order, as a single sequence.
+//
+// When a reader's input ends with an LF, and the next reader's input does not
+// begin with an RS after any leading whitespace, an RS is inserted between them,
+// so that the next reader's first value begins a new record rather than
+// extending the previous one. Otherwise the inputs are joined unmodified, so a
+// record split across readers is decoded whole. Leading bytes of the first
+// reader which do not begin with an RS form an invalid record.
+func NewMultiDecoder(readers ...io.Reader) *Decoder {
+	return NewDecoder(&multiReader{readers: readers})
+}
+
+// A multiReader is like io.MultiReader, but inserts an RS between readers where
+// a record boundary is missing.
+type multiReader struct {
+	readers []io.Reader
+	cur     *bufio.Reader
+	endLF   bool // Whether the input so far ends with an LF.
+}
+
+func (m *multiReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	for m.cur != nil || len(m.readers) > 0 {
+		if m.cur == nil {
+			m.cur = bufio.NewReader(m.readers[0])
+			m.readers = m.readers[1:]
+			if m.endLF && m.missingRS() {
+				p[0] = rs
+				m.endLF = false
+				return 1, nil
+			}
+		}
+		n, err := m.cur.Read(p)
+		if n > 0 {
+			// Whitespace-only reads extend the previous ending.
+			if endsWithLF(p[:n]) || len(bytes.TrimRightFunc(p[:n], wsRune)) > 0 {
+				m.endLF = endsWithLF(p[:n])
+			}
+		}
+		if err == io.EOF {
+			m.cur = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+	return 0, io.EOF
+}
+
+// missingRS reports whether the current reader's first byte other than leading
+// whitespace is not an RS. Only the first buffer of input is inspected.
+func (m *multiReader) missingRS() bool {
+	for n := 1; n <= m.cur.Size(); n++ {
+		b, _ := m.cur.Peek(n)
+		if len(b) < n {
+			return false
+		}
+		if !wsByte(b[n-1]) {
+			return b[n-1] != rs
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package jsonseq
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// NewNDJSONDecoder returns a Decoder which reads newline delimited JSON (NDJSON)
+// rather than a JSON text sequence, and is otherwise like NewDecoder.
+//
+// NDJSON frames each value with only a trailing line feed, so values may not
+// span lines, and a truncated final value cannot be distinguished by framing
+// alone. RFC 7464 framing begins each record with an RS instead, which permits
+// multi-line values, and allows recovery from truncated records.
+func NewNDJSONDecoder(r io.Reader) *Decoder {
+	d := NewDecoder(r)
+	d.split = ScanNDJSON
+	d.value = ndjsonValue
+	return d
+}
+
+// ndjsonValue trims leading whitespace from a line. Lines are always valid,
+// since framing is unambiguous.
+func ndjsonValue(b []byte) ([]byte, bool) {
+	return bytes.TrimLeftFunc(b, wsRune), true
+}
+
+func blank(b []byte) bool {
+	return len(bytes.TrimLeftFunc(b, wsRune)) == 0
+}
+
+// ScanNDJSON is a bufio.SplitFunc which splits newline delimited JSON (NDJSON)
+// lines, without the line feed. Blank lines are skipped.
+func ScanNDJSON(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	for advance < len(data) {
+		i := bytes.IndexByte(data[advance:], lf)
+		if i < 0 {
+			if !atEOF {
+				// Request more data.
+				return 0, nil, nil
+			}
+			// Final line.
+			if line := data[advance:]; !blank(line) {
+				return len(data), line, nil
+			}
+			return len(data), nil, nil
+		}
+		line := data[advance : advance+i]
+		advance += i + 1
+		if !blank(line) {
+			return advance, line, nil
+		}
+	}
+	return advance, nil, nil
+}
+
+// ToNDJSON reads records from src and writes each value to dst as newline
+// delimited JSON (NDJSON), compacting any multi-line values. It returns the
+// number of values written, and the first error annotated with its record
+// index.
+func ToNDJSON(dst io.Writer, src io.Reader) (int, error) {
+	d := NewDecoder(src)
+	var buf bytes.Buffer
+	for n := 0; ; n++ {
+		b, err := d.nextValue()
+		if err == io.EOF {
+			return n, nil
+		} else if err != nil {
+			return n, fmt.Errorf("record %d: %w", n, err)
+		}
+		buf.Reset()
+		if err := json.Compact(&buf, b); err != nil {
+			return n, fmt.Errorf("record %d: %w", n, err)
+		}
+		buf.WriteByte(lf)
+		if _, err := dst.Write(buf.Bytes()); err != nil {
+			return n, fmt.Errorf("record %d: %w", n, err)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package jsonseq
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Process decodes each record from r into an interface{}, as with NewDecoder,
+// and calls fn with the value from one of a pool of workers goroutines. Values
+// are passed to fn concurrently and in no particular order.
+//
+// The first decode or fn error cancels the remaining work and is returned,
+// annotated with the record index. Process does not return until all of its
+// goroutines have exited.
+func Process(ctx context.Context, r io.Reader, workers int, fn func(v interface{}) error) error {
+	return parallel(ctx, NewDecoder(r), workers, false, func(b []byte) (interface{}, error) {
+		var v interface{}
+		if err := decodeFirst(b, &v); err != nil {
+			return nil, err
+		}
+		return nil, fn(v)
+	}, nil)
+}
+
+// ProcessOrdered is like Process, except that while records are still decoded
+// concurrently by workers, fn is called sequentially and in record order.
+func ProcessOrdered(ctx context.Context, r io.Reader, workers int, fn func(v interface{}) error) error {
+	return parallel(ctx, NewDecoder(r), workers, true, func(b []byte) (interface{}, error) {
+		var v interface{}
+		err := decodeFirst(b, &v)
+		return v, err
+	}, fn)
+}
+
+// DecodeParallel reads records from r, and calls process with each value, from
+// one of a pool of workers goroutines, without trailing whitespace. The
+// returned bytes are written to out as records in the original record order.
+// As with Map, records for which process returns nil bytes and a nil error are
+// dropped.
+//
+// The first read, process, or write error cancels the remaining work and is
+// returned, annotated with the record index.
+func DecodeParallel(r io.Reader, workers int, process func(raw json.RawMessage) ([]byte, error), out io.Writer) error {
+	return parallel(context.Background(), NewDecoder(r), workers, true, func(b []byte) (interface{}, error) {
+		return process(bytes.TrimRightFunc(b, wsRune))
+	}, func(v interface{}) error {
+		if b := v.([]byte); b != nil {
+			return WriteRecord(out, b)
+		}
+		return nil
+	})
+}
+
+type result struct {
+	v   interface{}
+	err error
+}
+
+type job struct {
+	i   int
+	b   []byte
+	out chan result // Only when ordered.
+}
+
+// parallel reads record values from d, and calls work with each from one of a
+// pool of workers goroutines. When ordered, the results are passed to emit
+// sequentially in record order. The first error cancels the remaining work.
+func parallel(ctx context.Context, d *Decoder, workers int, ordered bool,
+	work func([]byte) (interface{}, error), emit func(interface{}) error) error {
+	if workers < 1 {
+		workers = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		once     sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	jobs := make(chan job)
+	pending := make(chan job, workers)
+
+	var wg sync.WaitGroup
+	wg.Add(workers + 1)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				var r result
+				if err := ctx.Err(); err != nil {
+					r.err = err
+				} else if r.v, r.err = work(j.b); r.err != nil {
+					r.err = fmt.Errorf("record %d: %w", j.i, r.err)
+				}
+				if ordered {
+					j.out <- r
+				} else if r.err != nil {
+					fail(r.err)
+				}
+			}
+		}()
+	}
+
+	// Feed records to the workers.
+	go func() {
+		defer wg.Done()
+		defer close(pending)
+		defer close(jobs)
+		// Abandon blocked reads once the work is cancelled.
+		d.r.ctx = ctx
+		defer func() { d.r.ctx = nil }()
+		for i := 0; ; i++ {
+			b, err := d.next()
+			if err == io.EOF || ctx.Err() != nil {
+				return
+			} else if err != nil {
+				fail(fmt.Errorf("record %d: %w", i, err))
+				return
+			}
+			j := job{i: i, b: append([]byte(nil), b...)}
+			if ordered {
+				j.out = make(chan result, 1)
+			}
+			select {
+			case jobs <- j:
+			case <-ctx.Done():
+				return
+			}
+			if ordered {
+				select {
+				case pending <- j:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	if ordered {
+		for j := range pending {
+			r := <-j.out
+			if r.err != nil {
+				fail(r.err)
+				break
+			}
+			if err := emit(r.v); err != nil {
+				fail(fmt.Errorf("record %d: %w", j.i, err))
+				break
+			}
+		}
+	}
+
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,134 @@
+package jsonseq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func seqOfInts(n int) string {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		sb.WriteString("\x1e" + strconv.Itoa(i) + "\n")
+	}
+	return sb.String()
+}
+
+func TestProcess(t *testing.T) {
+	const n = 100
+	var (
+		mu  sync.Mutex
+		got []int
+	)
+	err := Process(context.Background(), strings.NewReader(seqOfInts(n)), 4, func(v interface{}) error {
+		mu.Lock()
+		got = append(got, int(v.(float64)))
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Ints(got)
+	for i := range got {
+		if got[i] != i {
+			t.Fatalf("missing record %d: %v", i, got)
+		}
+	}
+	if len(got) != n {
+		t.Errorf("got %d records but want %d", len(got), n)
+	}
+}
+
+func TestProcessOrdered(t *testing.T) {
+	const n = 100
+	var got, want []int
+	err := ProcessOrdered(context.Background(), strings.NewReader(seqOfInts(n)), 4, func(v interface{}) error {
+		got = append(got, int(v.(float64)))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		want = append(want, i)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v but want %v", got, want)
+	}
+}
+
+func TestProcess_error(t *testing.T) {
+	errStop := errors.New("stop")
+	for _, ordered := range []bool{false, true} {
+		process := Process
+		if ordered {
+			process = ProcessOrdered
+		}
+		err := process(context.Background(), strings.NewReader(seqOfInts(1000)), 4, func(v interface{}) error {
+			if v.(float64) == 10 {
+				return errStop
+			}
+			return nil
+		})
+		if !errors.Is(err, errStop) {
+			t.Errorf("ordered=%t: expected %v but got %v", ordered, errStop, err)
+		}
+	}
+}
+
+func TestProcess_cancelBlockedRead(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	// Reads block until the deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := Process(ctx, pr, 2, func(v interface{}) error { return nil })
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v but got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestDecodeParallel(t *testing.T) {
+	const n = 100
+	var b strings.Builder
+	err := DecodeParallel(strings.NewReader(seqOfInts(n)), 4, func(raw json.RawMessage) ([]byte, error) {
+		i, err := strconv.Atoi(string(raw))
+		if err != nil {
+			return nil, err
+		}
+		if i%2 == 1 {
+			return nil, nil
+		}
+		return []byte(strconv.Itoa(i * 10)), nil
+	}, &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want strings.Builder
+	for i := 0; i < n; i += 2 {
+		want.WriteString("\x1e" + strconv.Itoa(i*10) + "\n")
+	}
+	if b.String() != want.String() {
+		t.Errorf("got %q but want %q", b.String(), want.String())
+	}
+
+	errTest := errors.New("test")
+	err = DecodeParallel(strings.NewReader(seqOfInts(n)), 4, func(raw json.RawMessage) ([]byte, error) {
+		if string(raw) == "42" {
+			return nil, errTest
+		}
+		return raw, nil
+	}, io.Discard)
+	if !errors.Is(err, errTest) || !strings.HasPrefix(err.Error(), "record 42") {
+		t.Errorf("expected record 42 error but got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package jsonseq
+
+import (
+	"io"
+	"sync"
+)
+
+var (
+	decoderPool sync.Pool
+	encoderPool sync.Pool
+)
+
+// GetDecoder returns a Decoder reading from r, like NewDecoder, but reused from
+// a pool if possible. Return it with PutDecoder once finished.
+func GetDecoder(r io.Reader) *Decoder {
+	if d, ok := decoderPool.Get().(*Decoder); ok {
+		d.Reset(r)
+		return d
+	}
+	return NewDecoder(r)
+}
+
+// PutDecoder returns d to the pool used by GetDecoder. Its reader, buffered
+// records, and any hooks are released, and its settings are restored to the
+// defaults of NewDecoder. The caller must not use d afterwards.
+func PutDecoder(d *Decoder) {
+	r, buf := d.r, d.buf
+	*r = ctxReader{buf: r.buf}
+	*d = Decoder{r: r, buf: buf}
+	d.init(decodeFirst)
+	decoderPool.Put(d)
+}
+
+// GetEncoder returns an Encoder writing to w, like NewEncoderT, but reused
+// from a pool if possible. Return it with PutEncoder once finished.
+func GetEncoder(w io.Writer) *Encoder {
+	if e, ok := encoderPool.Get().(*Encoder); ok {
+		e.Reset(w)
+		return e
+	}
+	return NewEncoderT(w)
+}
+
+// PutEncoder returns e to the pool used by GetEncoder. Its writer is released,
+// and its settings are restored to the defaults of NewEncoderT. The caller must
+// not use e afterwards.
+func PutEncoder(e *Encoder) {
+	e.Reset(nil)
+	e.buf.Reset()
+	e.fn = nil
+	e.sep = DefaultSeparators
+	e.autoFlush = false
+	e.preamble = 0
+	e.lengthPrefixed = false
+	e.enc.SetEscapeHTML(true)
+	e.enc.SetIndent("", "")
+	encoderPool.Put(e)
+}
@@ -0,0 +1,84 @@
+package jsonseq
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// A RecordReader reads JSON text sequence records as streams, rather than
+// buffering each one whole like a Decoder, so records are not limited in size.
+// Records are not validated.
+type RecordReader struct {
+	r   *bufio.Reader
+	cur *recordBody
+}
+
+// NewRecordReader returns a new RecordReader reading from r.
+func NewRecordReader(r io.Reader) *RecordReader {
+	return &RecordReader{r: bufio.NewReader(r)}
+}
+
+// Next advances to the next record, and returns a reader over its contents: the
+// bytes following the RS, up to the next RS or the end of the input. Any unread
+// contents of the previous record are discarded, as are any bytes preceding the
+// first RS. Next returns io.EOF when no records remain.
+func (r *RecordReader) Next() (io.Reader, error) {
+	if r.cur != nil {
+		if _, err := io.Copy(io.Discard, r.cur); err != nil {
+			return nil, err
+		}
+		r.cur = nil
+	}
+	// Find record start.
+	for {
+		_, err := r.r.ReadSlice(rs)
+		if err == nil {
+			break
+		} else if err != bufio.ErrBufferFull {
+			return nil, err
+		}
+	}
+	// Drop consecutive leading rs's.
+	for {
+		b, err := r.r.Peek(1)
+		if err != nil || b[0] != rs {
+			break
+		}
+		_, _ = r.r.Discard(1)
+	}
+	r.cur = &recordBody{r: r.r}
+	return r.cur, nil
+}
+
+// A recordBody reads from r up to the next RS.
+type recordBody struct {
+	r    *bufio.Reader
+	done bool
+}
+
+func (b *recordBody) Read(p []byte) (int, error) {
+	if b.done {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if _, err := b.r.Peek(1); err != nil {
+		if err == io.EOF {
+			b.done = true
+		}
+		return 0, err
+	}
+	buf, _ := b.r.Peek(b.r.Buffered())
+	if i := bytes.IndexByte(buf, rs); i >= 0 {
+		if i == 0 {
+			b.done = true
+			return 0, io.EOF
+		}
+		buf = buf[:i]
+	}
+	n := copy(p, buf)
+	_, _ = b.r.Discard(n)
+	return n, nil
+}
@@ -0,0 +1,34 @@
+package jsonseq
+
+import "io"
+
+// A RecordScanner iterates over the raw value bytes of JSON text sequence
+// records, like a bufio.Scanner split by ScanRecord, with each record checked
+// by RecordValue. Values are not parsed. It is a bufio.Scanner style wrapper
+// around Decoder.Next.
+type RecordScanner struct {
+	d *Decoder
+}
+
+// NewRecordScanner returns a new RecordScanner reading from r.
+func NewRecordScanner(r io.Reader) *RecordScanner {
+	return &RecordScanner{d: NewDecoder(r)}
+}
+
+// Scan advances to the next record, which is then available via Record. It
+// returns false at the end of the input, or after an error, including an
+// invalid record, which is reported by Err.
+func (s *RecordScanner) Scan() bool {
+	return s.d.Next()
+}
+
+// Record returns the value bytes of the current record, without trailing
+// whitespace. The bytes are only valid until the next call to Scan.
+func (s *RecordScanner) Record() []byte {
+	return s.d.RawValue()
+}
+
+// Err returns the first non-EOF error encountered by Scan.
+func (s *RecordScanner) Err() error {
+	return s.d.Err()
+}
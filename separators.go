@@ -0,0 +1,79 @@
+package jsonseq
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+)
+
+// Separators are the marker bytes framing each record, which may be configured
+// to interoperate with non-standard dialects. Newline delimited dialects, with
+// no start marker, are supported by NewNDJSONDecoder instead.
+type Separators struct {
+	Start byte // Begins each record. Required.
+	End   byte // Ends each record, or 0 for none.
+}
+
+// DefaultSeparators are the RS and LF markers specified by RFC 7464.
+var DefaultSeparators = Separators{Start: rs, End: lf}
+
+func (s Separators) validate() error {
+	if s.Start == 0 {
+		return errors.New("missing start separator")
+	}
+	if s.Start == s.End {
+		return errors.New("start and end separators must differ")
+	}
+	return nil
+}
+
+// split returns a bufio.SplitFunc for records beginning with s.Start.
+func (s Separators) split() bufio.SplitFunc {
+	return MakeScanRecord(s.Start)
+}
+
+// recordValue is like RecordValue for records framed by s. An End marker also
+// terminates values, and is trimmed along with any trailing whitespace. Without
+// an End marker, truncated values cannot be detected.
+func (s Separators) recordValue(b []byte) ([]byte, bool) {
+	if len(b) < 2 || b[0] != s.Start {
+		return b, false
+	}
+	// Drop start marker and leading whitespace.
+	b = bytes.TrimLeftFunc(b[1:], wsRune)
+	switch {
+	case s.End == 0:
+		return b, true
+	case wsByte(s.End):
+		return b, validValue(b, wsSet)
+	}
+	if !validValue(b, append([]byte{s.End}, wsSet...)) {
+		return b, false
+	}
+	t := bytes.TrimRightFunc(b, wsRune)
+	if len(t) > 0 && t[len(t)-1] == s.End {
+		b = t[:len(t)-1]
+	}
+	return b, true
+}
+
+// SetSeparators configures the marker bytes framing records, which default to
+// DefaultSeparators. It must be called before decoding.
+func (d *Decoder) SetSeparators(s Separators) error {
+	if err := s.validate(); err != nil {
+		return err
+	}
+	d.split = s.split()
+	d.value = s.recordValue
+	return nil
+}
+
+// SetSeparators configures the marker bytes framing records, which default to
+// DefaultSeparators.
+func (e *Encoder) SetSeparators(s Separators) error {
+	if err := s.validate(); err != nil {
+		return err
+	}
+	e.sep = s
+	return nil
+}
@@ -0,0 +1,74 @@
+package jsonseq
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// Rules of RFC 7464 enforced by Decoder.SetStrict.
+var (
+	// ErrNoRS is the rule that every record must begin with an RS.
+	ErrNoRS = errors.New("record does not begin with RS")
+	// ErrTruncated is the rule that a top-level number, true, false, or null
+	// must be followed by whitespace, so that truncation can be detected.
+	ErrTruncated = errors.New("record value is truncated")
+	// ErrNoLF is the rule that every record must end with an LF.
+	ErrNoLF = errors.New("record does not end with LF")
+	// ErrMultipleValues is the rule that every record must hold a single JSON
+	// text, such as a string not immediately followed by another value.
+	ErrMultipleValues = errors.New("record holds multiple values")
+)
+
+// A StrictError reports a record which violates a rule of RFC 7464.
+type StrictError struct {
+	Rule   error  // One of ErrNoRS, ErrTruncated, ErrNoLF, or ErrMultipleValues.
+	Record []byte // The raw record, including framing.
+}
+
+func (e *StrictError) Error() string {
+	return fmt.Sprintf("%v: %q", e.Rule, string(e.Record))
+}
+
+func (e *StrictError) Unwrap() error {
+	return e.Rule
+}
+
+// strictRule returns the first rule violated by the record raw, or nil. The
+// flag valid is the result of RecordValue.
+func strictRule(raw []byte, valid bool) error {
+	switch {
+	case len(raw) == 0 || raw[0] != rs:
+		return ErrNoRS
+	case !valid && !blank(raw[1:]):
+		return ErrTruncated
+	case !endsWithLF(raw):
+		return ErrNoLF
+	}
+	return nil
+}
+
+// multipleValues reports whether the value bytes b hold data following the
+// first value. Syntax errors are left to be reported by decoding.
+func multipleValues(b []byte) bool {
+	var td *TrailingDataError
+	return errors.As(checkSingleValue(b), &td)
+}
+
+// endsWithLF reports whether the trailing whitespace of raw includes an LF.
+func endsWithLF(raw []byte) bool {
+	return bytes.IndexByte(raw[len(bytes.TrimRightFunc(raw, wsRune)):], lf) >= 0
+}
+
+// ScanRecordStrict is like ScanRecord, but rather than returning any bytes
+// preceding the first RS as a partial record, it fails with an error wrapping
+// ErrNoRS, stopping the scanner.
+func ScanRecordStrict(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if len(data) > 0 && data[0] != rs {
+		if i := bytes.IndexByte(data, rs); i >= 0 {
+			data = data[:i]
+		}
+		return 0, nil, fmt.Errorf("%w: %q", ErrNoRS, string(data))
+	}
+	return ScanRecord(data, atEOF)
+}
@@ -0,0 +1,45 @@
+package jsonseq
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// ErrRecordEnd is returned by Decoder.Token after the last token of a record.
+var ErrRecordEnd = errors.New("end of record")
+
+// Token returns the next JSON token of the current record, like
+// json.Decoder.Token, scanning the next record as necessary. After the last
+// token of a record, Token returns ErrRecordEnd, and the following call begins
+// the next record. Token returns io.EOF when no records remain. Calling Decode
+// or other methods which consume a record discards any remaining tokens.
+func (d *Decoder) Token() (json.Token, error) {
+	if d.tok == nil {
+		tok, err := d.NextDecoder()
+		if err != nil {
+			return nil, err
+		}
+		d.tok = tok
+	}
+	t, err := d.tok.Token()
+	if err == io.EOF {
+		d.tok = nil
+		return nil, ErrRecordEnd
+	}
+	return t, err
+}
+
+// NextDecoder scans the next record, and returns a standard library
+// json.Decoder reading from a copy of its value bytes, so that the caller may
+// configure and drive decoding directly, e.g. with UseNumber, Token, and More.
+// The record is checked by RecordValue, but not parsed. NextDecoder returns
+// io.EOF when no records remain.
+func (d *Decoder) NextDecoder() (*json.Decoder, error) {
+	b, err := d.nextValue()
+	if err != nil {
+		return nil, err
+	}
+	return json.NewDecoder(bytes.NewReader(append([]byte(nil), b...))), nil
+}
@@ -0,0 +1,182 @@
+package jsonseq
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+)
+
+// PrettyDiffForm reads records from r and writes them to w in a canonical,
+// pretty printed form suitable for diffing: object keys are sorted, nesting is
+// indented by two spaces, and numbers are preserved as written. Each value is
+// framed as a separate record.
+func PrettyDiffForm(r io.Reader, w io.Writer) error {
+	d := NewDecoder(r)
+	enc := NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	for i := 0; ; i++ {
+		b, err := d.next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("record %d: %w", i, err)
+		}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return fmt.Errorf("record %d: %w", i, err)
+		}
+		if err := enc.Encode(v); err != nil {
+			return fmt.Errorf("record %d: %w", i, err)
+		}
+	}
+}
+
+// A ShardStrategy chooses which of n shards the i'th record, with the given
+// value bytes, is written to.
+type ShardStrategy func(i int, value []byte, n int) (int, error)
+
+// RoundRobin is a ShardStrategy which cycles through the shards in order.
+func RoundRobin(i int, _ []byte, n int) (int, error) {
+	return i % n, nil
+}
+
+// ByKey returns a ShardStrategy which hashes the value of key within each
+// record's top-level object, so that records with equal key values are written
+// to the same shard. The key value is hashed as written, and records without
+// the key are treated as if it were null.
+func ByKey(key string) ShardStrategy {
+	return func(_ int, value []byte, n int) (int, error) {
+		var obj map[string]json.RawMessage
+		if err := decodeFirst(value, &obj); err != nil {
+			return 0, err
+		}
+		k, ok := obj[key]
+		if !ok {
+			k = json.RawMessage("null")
+		}
+		h := fnv.New32a()
+		_, _ = h.Write(k)
+		return int(h.Sum32() % uint32(n)), nil
+	}
+}
+
+// Shard reads records from r and writes each whole record to one of shards,
+// as chosen by strategy, so that each shard is itself a valid sequence. A nil
+// strategy is RoundRobin. Value bytes are copied unmodified, as returned by
+// RecordValue. Shard returns the number of records written, and any error
+// annotated with the record index.
+func Shard(r io.Reader, shards []io.Writer, strategy ShardStrategy) (int, error) {
+	if len(shards) == 0 {
+		return 0, errors.New("no shards")
+	}
+	if strategy == nil {
+		strategy = RoundRobin
+	}
+	d := NewDecoder(r)
+	for i := 0; ; i++ {
+		b, err := d.nextValue()
+		if err == io.EOF {
+			return i, nil
+		} else if err != nil {
+			return i, fmt.Errorf("record %d: %w", i, err)
+		}
+		s, err := strategy(i, b, len(shards))
+		if err != nil {
+			return i, fmt.Errorf("record %d: %w", i, err)
+		}
+		if s < 0 || s >= len(shards) {
+			return i, fmt.Errorf("record %d: shard %d out of range", i, s)
+		}
+		if err := WriteRecord(shards[s], b); err != nil {
+			return i, fmt.Errorf("record %d: %w", i, err)
+		}
+	}
+}
+
+// Filter reads records from src and writes those for which keep returns true
+// to dst, with value bytes copied unmodified. The value passed to keep is only
+// valid until it returns. Filter returns the number of records kept and read,
+// and any error annotated with the record index.
+func Filter(dst io.Writer, src io.Reader, keep func(raw json.RawMessage) bool) (kept, total int, err error) {
+	d := NewDecoder(src)
+	for ; ; total++ {
+		b, err := d.nextValue()
+		if err == io.EOF {
+			return kept, total, nil
+		} else if err != nil {
+			return kept, total, fmt.Errorf("record %d: %w", total, err)
+		}
+		if !keep(b) {
+			continue
+		}
+		if err := WriteRecord(dst, b); err != nil {
+			return kept, total, fmt.Errorf("record %d: %w", total, err)
+		}
+		kept++
+	}
+}
+
+// Map reads records from src, and writes the bytes returned by fn for each
+// value to dst as a record. Records for which fn returns nil bytes and a nil
+// error are dropped. The value passed to fn is only valid until it returns. Map
+// returns the number of records written, and any error annotated with the
+// record index.
+func Map(dst io.Writer, src io.Reader, fn func(raw json.RawMessage) ([]byte, error)) (int, error) {
+	d := NewDecoder(src)
+	var n int
+	for i := 0; ; i++ {
+		b, err := d.nextValue()
+		if err == io.EOF {
+			return n, nil
+		} else if err != nil {
+			return n, fmt.Errorf("record %d: %w", i, err)
+		}
+		out, err := fn(b)
+		if err != nil {
+			return n, fmt.Errorf("record %d: %w", i, err)
+		}
+		if out == nil {
+			continue
+		}
+		if err := WriteRecord(dst, out); err != nil {
+			return n, fmt.Errorf("record %d: %w", i, err)
+		}
+		n++
+	}
+}
+
+// CopyRecords copies records from src to dst losslessly: each record is only
+// checked by RecordValue, and its value bytes are written unmodified, without
+// surrounding whitespace, with fresh RS and LF framing. It returns the number of
+// records copied, and any error annotated with the record index.
+func CopyRecords(dst io.Writer, src io.Reader) (int, error) {
+	n, _, err := Filter(dst, src, func(json.RawMessage) bool { return true })
+	return n, err
+}
+
+// Reindent reads records from src, and writes each value to dst as a record,
+// indented per json.Indent, or compacted per json.Compact if both prefix and
+// indent are empty. It returns the number of records written, and any error,
+// including invalid JSON, annotated with the record index.
+func Reindent(dst io.Writer, src io.Reader, prefix, indent string) (int, error) {
+	var buf bytes.Buffer
+	return Map(dst, src, func(raw json.RawMessage) ([]byte, error) {
+		buf.Reset()
+		var err error
+		if prefix == "" && indent == "" {
+			err = json.Compact(&buf, raw)
+		} else {
+			err = json.Indent(&buf, raw, prefix, indent)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	})
+}
@@ -0,0 +1,72 @@
+package jsonseq
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrInvalidJSON is returned when a record value is not valid JSON.
+var ErrInvalidJSON = errors.New("invalid JSON")
+
+// ErrEmbeddedRS is returned by WriteRecordSafe when a value contains an RS,
+// which would corrupt the sequence.
+var ErrEmbeddedRS = errors.New("value contains RS")
+
+// ErrTooManyRecords is returned by ValidateStream when a sequence holds more
+// records than allowed.
+var ErrTooManyRecords = errors.New("too many records")
+
+// ValidateStream checks that every record read from r is well framed, per
+// RecordValue, and holds a single valid JSON value, per json.Valid. It returns
+// the number of valid records, and the first error annotated with its record
+// index.
+//
+// If maxRecords is positive, then sequences with more than maxRecords records
+// are rejected with ErrTooManyRecords. Otherwise the number is unlimited.
+func ValidateStream(r io.Reader, maxRecords int) (int, error) {
+	d := NewDecoder(r)
+	for n := 0; ; n++ {
+		b, err := d.next()
+		if err == io.EOF {
+			return n, nil
+		} else if err != nil {
+			return n, fmt.Errorf("record %d: %w", n, err)
+		}
+		if maxRecords > 0 && n >= maxRecords {
+			return n, fmt.Errorf("record %d: %w: limit is %d", n, ErrTooManyRecords, maxRecords)
+		}
+		if !json.Valid(b) {
+			return n, fmt.Errorf("record %d: %w: %q", n, ErrInvalidJSON, string(b))
+		}
+	}
+}
+
+// Valid checks that every record read from r is well framed, and holds valid
+// JSON, as with ValidateStream without a record limit. It returns the number
+// of valid records, and the first error annotated with its record index.
+func Valid(r io.Reader) (records int, err error) {
+	return ValidateStream(r, 0)
+}
+
+// WriteRecordValid is like WriteRecord, but first checks that b is a valid JSON
+// value, per json.Valid, and returns ErrInvalidJSON without writing anything if
+// not.
+func WriteRecordValid(w io.Writer, b []byte) error {
+	if !json.Valid(b) {
+		return fmt.Errorf("%w: %q", ErrInvalidJSON, string(b))
+	}
+	return WriteRecord(w, b)
+}
+
+// WriteRecordSafe is like WriteRecord, but first checks that b does not contain
+// an RS, and returns ErrEmbeddedRS without writing anything if it does. Valid
+// JSON never contains an unescaped RS, so this cheaply catches corrupt values.
+func WriteRecordSafe(w io.Writer, b []byte) error {
+	if i := bytes.IndexByte(b, rs); i >= 0 {
+		return fmt.Errorf("%w at offset %d", ErrEmbeddedRS, i)
+	}
+	return WriteRecord(w, b)
+}
@@ -0,0 +1,100 @@
+package jsonseq
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// A Writer validates and re-frames a JSON text sequence written to it, and
+// writes the records to an underlying io.Writer. Each value is written without
+// surrounding whitespace, as a record with a single LF. It implements
+// io.ReaderFrom, so that io.Copy from a sequence reads records directly, but
+// the result is the same when io.Copy writes to it instead, such as from a
+// source implementing io.WriterTo. Callers must call Close after the last
+// write.
+type Writer struct {
+	w   io.Writer
+	buf []byte // Input not yet written, beginning with a partial record.
+	n   int    // Records scanned.
+}
+
+// NewWriter returns a new Writer writing to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write writes the JSON text sequence bytes p, which may begin or end part way
+// through a record. A record is complete once the next begins, so the last is
+// buffered until more is written, or Close. Invalid records, per RecordValue,
+// are returned as errors, annotated with the record index.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	if err := w.flush(false); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close writes the final record buffered by Write, if any. It does not close
+// the underlying io.Writer.
+func (w *Writer) Close() error {
+	return w.flush(true)
+}
+
+// flush writes each complete buffered record, and the last one too if atEOF.
+func (w *Writer) flush(atEOF bool) error {
+	var off int
+	defer func() { w.buf = w.buf[:copy(w.buf, w.buf[off:])] }()
+	for {
+		advance, token, _ := ScanRecord(w.buf[off:], atEOF)
+		if advance == 0 {
+			return nil
+		}
+		off += advance
+		i := w.n
+		w.n++
+		b, ok := RecordValue(token)
+		if !ok {
+			return fmt.Errorf("record %d: %w", i, &InvalidRecordError{Record: append([]byte(nil), b...)})
+		}
+		if err := WriteRecord(w.w, bytes.TrimRightFunc(b, wsRune)); err != nil {
+			return fmt.Errorf("record %d: %w", i, err)
+		}
+	}
+}
+
+// ReadFrom reads records from r until EOF, following any input buffered by
+// Write, and writes each value, without surrounding whitespace, as a record
+// with a single LF. It returns the number of bytes written, including framing,
+// and the first error annotated with its record index. Invalid records, per
+// RecordValue, are returned as errors.
+func (w *Writer) ReadFrom(r io.Reader) (int64, error) {
+	if len(w.buf) > 0 {
+		r = io.MultiReader(bytes.NewReader(w.buf), r)
+		w.buf = nil
+	}
+	return NewDecoder(r).DrainTo(w.w)
+}
+
+// DrainTo writes each remaining record, starting with any buffered by Peek or
+// More, to w, with its value bytes unmodified, without surrounding whitespace,
+// and framed with a single LF. It returns the number of bytes written,
+// including framing, and the first error annotated with its index among the
+// drained records.
+func (d *Decoder) DrainTo(w io.Writer) (int64, error) {
+	var n int64
+	for i := 0; ; i++ {
+		b, err := d.nextValue()
+		if err == io.EOF {
+			return n, nil
+		} else if err != nil {
+			return n, fmt.Errorf("record %d: %w", i, err)
+		}
+		m, err := WriteRecordN(w, b)
+		n += int64(m)
+		if err != nil {
+			return n, fmt.Errorf("record %d: %w", i, err)
+		}
+	}
+}